@@ -68,6 +68,10 @@ func main() {
 		log.Panicf("failed to load app config: %v", err)
 	}
 
+	for _, problem := range appConfig.Validate().Filter(configuration.SeverityWarning) {
+		log.Printf("Config %s\n", problem)
+	}
+
 	// detect "go run" execution or allow explicit override
 	isDev := appConfig.Server.ForceDevMode ||
 		strings.Contains(os.Args[0], os.TempDir())
@@ -75,11 +79,6 @@ func main() {
 	// Inject CLI arguments from configuration
 	os.Args = configuration.InjectCLIArgs(os.Args, appConfig)
 
-	// Print debug information if enabled
-	if _, err := configuration.DebugPrintIfEnabled(nil); err != nil {
-		log.Printf("Warning: failed to print debug info: %v", err)
-	}
-
 	if isDev {
 		log.Printf("Application is starting (is dev: %v)...\n", isDev)
 	}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// RegisterConfigAPI registers the configuration endpoints with the PocketBase server.
+//
+// GET /api/config/public, mounted below the configured base path, responses:
+//
+//	200 OK - The AppConfig fields tagged with `public:"true"`, nested by their JSON names,
+//	         e.g. {"general":{"name":"...","description":"...","version":"...","url":"..."}}.
+//	         The same object is available to the frontend as window.__APP_CONFIG__.
+//
+// POST /api/config/reload, mounted below the configured base path, superusers only, responses:
+//
+//	200 OK           - {"changes":[{"path":"...","env":"...","live":bool}],"restartRequired":["..."],"warnings":["..."]}
+//	                   The changes with "live" set are applied, the others need a restart.
+//	401/403          - If the request is not authenticated as a superuser.
+//	422 Unprocessable - If the config fails to load or validate, the previous config is kept.
+func RegisterConfigAPI(app *pocketbase.PocketBase, cfg configuration.AppConfig) {
+	live := configuration.Live(cfg)
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET(configuration.MountPath(cfg, "/api/config/public"), func(e *core.RequestEvent) error {
+			return e.JSON(http.StatusOK, configuration.PublicConfig(*live.Load()))
+		})
+
+		se.Router.POST(configuration.MountPath(cfg, "/api/config/reload"), func(e *core.RequestEvent) error {
+			result, err := configuration.Reload()
+			if err != nil {
+				log.Printf("Failed to reload the config through the API, keeping the previous one: %v\n", err)
+				return e.Error(
+					http.StatusUnprocessableEntity,
+					fmt.Sprintf("Failed to reload the config: %v.", err),
+					err,
+				)
+			}
+			log.Printf("Reloaded the config through the API: %s.\n", result)
+
+			restartRequired := []string{}
+			for _, change := range result.RestartRequired() {
+				restartRequired = append(restartRequired, change.Path)
+			}
+			warnings := []string{}
+			for _, problem := range result.Warnings {
+				warnings = append(warnings, problem.String())
+			}
+
+			changes := result.Changes
+			if changes == nil {
+				changes = []configuration.Change{}
+			}
+
+			return e.JSON(http.StatusOK, map[string]any{
+				"changes":         changes,
+				"restartRequired": restartRequired,
+				"warnings":        warnings,
+			})
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+}
@@ -23,6 +23,7 @@ var userMutex = sync.Mutex{}
 // - GET  /api/user/exists            : Determines if any user accounts beyond the default exist.
 // - POST /api/user/create-admin-user : Creates the first normal user and matching superuser when none exist.
 // - GET  /api/user/is-authenticated  : Checks if the current request is authenticated and if admin creation is allowed.
+// The routes are mounted below the configured base path, see configuration.MountPath.
 //
 // GET /api/user/exists responses:
 //
@@ -40,6 +41,7 @@ var userMutex = sync.Mutex{}
 //
 //	200 OK    - {"success":true} on successful account creation.
 //	400 Bad Request - Missing/invalid parameters or password mismatch/length issues.
+//	404 Not Found   - When the initial admin registration is disabled, which a config reload may toggle.
 //	409 Conflict    - When users already exist or unexpected superuser state.
 //	500 Error       - On database operation failures or transaction rollbacks.
 //
@@ -73,203 +75,213 @@ func RegisterUserAPI(app *pocketbase.PocketBase, cfg configuration.AppConfig) {
 		return false, nil
 	}
 
+	// the registration can be toggled by reloading the config
+	live := configuration.Live(cfg)
+
 	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
-		if cfg.General.InitialAdminRegistration {
-			// Handler: POST /api/user/create-admin-user
-			// Purpose: Initializes the first normal user and a matching superuser when no users exist.
-			// Parameters (form):
-			//   - email           string (required): email address for new accounts.
-			//   - password        string (required): password for new accounts (min length 10).
-			//   - passwordConfirm string (required): must match 'password'.
-			// Responses:
-			//   200: {"success": true} on successful creation.
-			//   400: Bad request on missing or invalid parameters.
-			//   409: Conflict if users already exist or superuser count mismatch.
-			//   500: Internal error on database or transaction failures.
-			se.Router.POST("/api/user/create-admin-user", func(e *core.RequestEvent) error {
-				userMutex.Lock()
-				defer userMutex.Unlock()
-
-				totalUsers, err := app.CountRecords("users")
-				if err != nil {
-					return e.Error(
-						http.StatusInternalServerError,
-						fmt.Sprintf(
-							"Failed to count records in 'users' collection: %v. Please check database connection and collection name.",
-							err,
-						),
+		// Handler: POST /api/user/create-admin-user
+		// Purpose: Initializes the first normal user and a matching superuser when no users exist.
+		// Parameters (form):
+		//   - email           string (required): email address for new accounts.
+		//   - password        string (required): password for new accounts (min length 10).
+		//   - passwordConfirm string (required): must match 'password'.
+		// Responses:
+		//   200: {"success": true} on successful creation.
+		//   400: Bad request on missing or invalid parameters.
+		//   404: Not found if the initial admin registration is disabled.
+		//   409: Conflict if users already exist or superuser count mismatch.
+		//   500: Internal error on database or transaction failures.
+		se.Router.POST(configuration.MountPath(cfg, "/api/user/create-admin-user"), func(e *core.RequestEvent) error {
+			if !live.Load().General.InitialAdminRegistration {
+				return e.Error(
+					http.StatusNotFound,
+					"The initial admin registration is disabled.",
+					nil,
+				)
+			}
+
+			userMutex.Lock()
+			defer userMutex.Unlock()
+
+			totalUsers, err := app.CountRecords("users")
+			if err != nil {
+				return e.Error(
+					http.StatusInternalServerError,
+					fmt.Sprintf(
+						"Failed to count records in 'users' collection: %v. Please check database connection and collection name.",
 						err,
-					)
-				}
-				if totalUsers > 0 {
-					return e.Error(
-						http.StatusConflict,
-						fmt.Sprintf(
-							"User creation endpoint can only be called when no users exist. Found %d existing users. Please remove all existing users and try again.",
-							totalUsers,
-						),
-						nil,
-					)
-				}
+					),
+					err,
+				)
+			}
+			if totalUsers > 0 {
+				return e.Error(
+					http.StatusConflict,
+					fmt.Sprintf(
+						"User creation endpoint can only be called when no users exist. Found %d existing users. Please remove all existing users and try again.",
+						totalUsers,
+					),
+					nil,
+				)
+			}
 
-				existingSuperusers, err := app.FindAllRecords(core.CollectionNameSuperusers)
-				if err != nil {
-					return e.Error(
-						http.StatusInternalServerError,
-						fmt.Sprintf(
-							"Failed to retrieve records from '%s' collection: %v. Please ensure the superusers collection exists and database is reachable.",
-							core.CollectionNameSuperusers,
-							err,
-						),
+			existingSuperusers, err := app.FindAllRecords(core.CollectionNameSuperusers)
+			if err != nil {
+				return e.Error(
+					http.StatusInternalServerError,
+					fmt.Sprintf(
+						"Failed to retrieve records from '%s' collection: %v. Please ensure the superusers collection exists and database is reachable.",
+						core.CollectionNameSuperusers,
 						err,
-					)
-				}
-				if len(existingSuperusers) != 1 {
-					return e.Error(
-						http.StatusConflict,
-						fmt.Sprintf(
-							"Unexpected superuser count. Expected exactly 1 default superuser but found %d records. Please verify the superusers collection.",
-							len(existingSuperusers),
-						),
-						nil,
-					)
-				}
+					),
+					err,
+				)
+			}
+			if len(existingSuperusers) != 1 {
+				return e.Error(
+					http.StatusConflict,
+					fmt.Sprintf(
+						"Unexpected superuser count. Expected exactly 1 default superuser but found %d records. Please verify the superusers collection.",
+						len(existingSuperusers),
+					),
+					nil,
+				)
+			}
 
-				if existingSuperusers[0].GetString("email") != migrations.InitialAdminEmail {
-					return e.Error(
-						http.StatusInternalServerError,
-						fmt.Sprintf(
-							"Initial superuser email mismatch. Expected '%s' but found '%s'. Please check the initial migration settings.",
-							migrations.InitialAdminEmail,
-							existingSuperusers[0].GetString("email"),
-						),
-						nil,
-					)
-				}
+			if existingSuperusers[0].GetString("email") != migrations.InitialAdminEmail {
+				return e.Error(
+					http.StatusInternalServerError,
+					fmt.Sprintf(
+						"Initial superuser email mismatch. Expected '%s' but found '%s'. Please check the initial migration settings.",
+						migrations.InitialAdminEmail,
+						existingSuperusers[0].GetString("email"),
+					),
+					nil,
+				)
+			}
 
-				superusers, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
-				if err != nil {
-					return e.Error(
-						http.StatusInternalServerError,
-						fmt.Sprintf(
-							"Failed to locate superusers collection metadata: %v. Please verify migrations have been applied and collection identifier is correct.",
-							err,
-						),
+			superusers, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+			if err != nil {
+				return e.Error(
+					http.StatusInternalServerError,
+					fmt.Sprintf(
+						"Failed to locate superusers collection metadata: %v. Please verify migrations have been applied and collection identifier is correct.",
 						err,
-					)
-				}
+					),
+					err,
+				)
+			}
 
-				users, err := app.FindCollectionByNameOrId("users")
-				if err != nil {
-					return e.Error(
-						http.StatusInternalServerError,
-						fmt.Sprintf(
-							"Failed to locate users collection metadata: %v. Please verify migrations and collection identifier.",
-							err,
-						),
+			users, err := app.FindCollectionByNameOrId("users")
+			if err != nil {
+				return e.Error(
+					http.StatusInternalServerError,
+					fmt.Sprintf(
+						"Failed to locate users collection metadata: %v. Please verify migrations and collection identifier.",
 						err,
-					)
-				}
+					),
+					err,
+				)
+			}
 
-				email := e.Request.FormValue("email")
-				if email == "" {
-					return e.Error(
-						http.StatusBadRequest,
-						"Missing 'email' parameter. Please provide a valid email address for the new user.",
-						nil,
-					)
-				}
+			email := e.Request.FormValue("email")
+			if email == "" {
+				return e.Error(
+					http.StatusBadRequest,
+					"Missing 'email' parameter. Please provide a valid email address for the new user.",
+					nil,
+				)
+			}
 
-				password := e.Request.FormValue("password")
-				if password == "" {
-					return e.Error(
-						http.StatusBadRequest,
-						"Missing 'password' parameter. Please provide a secure password for the new user (minimum length 10 characters).",
-						nil,
-					)
-				}
+			password := e.Request.FormValue("password")
+			if password == "" {
+				return e.Error(
+					http.StatusBadRequest,
+					"Missing 'password' parameter. Please provide a secure password for the new user (minimum length 10 characters).",
+					nil,
+				)
+			}
 
-				passwordConfirm := e.Request.FormValue("passwordConfirm")
-				if passwordConfirm == "" {
-					return e.Error(
-						http.StatusBadRequest,
-						"Missing 'passwordConfirm' parameter. Please confirm the password by providing the same value as 'password'.",
-						nil,
-					)
-				}
+			passwordConfirm := e.Request.FormValue("passwordConfirm")
+			if passwordConfirm == "" {
+				return e.Error(
+					http.StatusBadRequest,
+					"Missing 'passwordConfirm' parameter. Please confirm the password by providing the same value as 'password'.",
+					nil,
+				)
+			}
 
-				if password != passwordConfirm {
-					return e.Error(
-						http.StatusBadRequest,
-						"Password and confirmation do not match. Please ensure both 'password' and 'passwordConfirm' values are identical.",
-						nil,
-					)
+			if password != passwordConfirm {
+				return e.Error(
+					http.StatusBadRequest,
+					"Password and confirmation do not match. Please ensure both 'password' and 'passwordConfirm' values are identical.",
+					nil,
+				)
+			}
+
+			if len(password) < 10 {
+				return e.Error(
+					http.StatusBadRequest,
+					fmt.Sprintf(
+						"Password length must be at least 10 characters. Provided length: %d. Please choose a longer password for security.",
+						len(password),
+					),
+					nil,
+				)
+			}
+
+			normalUser := core.NewRecord(users)
+			normalUser.SetEmail(email)
+			normalUser.SetPassword(password)
+			normalUser.SetEmailVisibility(false)
+			normalUser.SetVerified(true)
+
+			superUser := core.NewRecord(superusers)
+			superUser.SetEmail(email)
+			superUser.SetPassword(password)
+
+			err = app.RunInTransaction(func(txApp core.App) error {
+				err = txApp.Save(normalUser)
+				if err != nil {
+					return fmt.Errorf("could not save user: %w", err)
 				}
 
-				if len(password) < 10 {
-					return e.Error(
-						http.StatusBadRequest,
-						fmt.Sprintf(
-							"Password length must be at least 10 characters. Provided length: %d. Please choose a longer password for security.",
-							len(password),
-						),
-						nil,
-					)
+				err = txApp.Save(superUser)
+				if err != nil {
+					return fmt.Errorf("could not save super user: %w", err)
 				}
 
-				normalUser := core.NewRecord(users)
-				normalUser.SetEmail(email)
-				normalUser.SetPassword(password)
-				normalUser.SetEmailVisibility(false)
-				normalUser.SetVerified(true)
-
-				superUser := core.NewRecord(superusers)
-				superUser.SetEmail(email)
-				superUser.SetPassword(password)
-
-				err = app.RunInTransaction(func(txApp core.App) error {
-					err = txApp.Save(normalUser)
-					if err != nil {
-						return fmt.Errorf("could not save user: %w", err)
-					}
-
-					err = txApp.Save(superUser)
-					if err != nil {
-						return fmt.Errorf("could not save super user: %w", err)
-					}
-
-					err = txApp.Delete(existingSuperusers[0])
-					if err != nil {
-						return fmt.Errorf("could not delete initial super user: %w", err)
-					}
-
-					return nil
-				})
+				err = txApp.Delete(existingSuperusers[0])
 				if err != nil {
-					return e.Error(
-						http.StatusInternalServerError,
-						fmt.Sprintf(
-							"Failed to create user and superuser transactionally: %v. Ensure input data is valid and database is operational.",
-							err,
-						),
-						err,
-					)
+					return fmt.Errorf("could not delete initial super user: %w", err)
 				}
 
-				return e.JSON(http.StatusOK, map[string]bool{"success": true})
+				return nil
 			})
-		}
+			if err != nil {
+				return e.Error(
+					http.StatusInternalServerError,
+					fmt.Sprintf(
+						"Failed to create user and superuser transactionally: %v. Ensure input data is valid and database is operational.",
+						err,
+					),
+					err,
+				)
+			}
+
+			return e.JSON(http.StatusOK, map[string]bool{"success": true})
+		})
 
 		// Handler: GET /api/user/is-authenticated
 		// Purpose: Checks if the current request is authenticated and if admin creation is allowed.
 		// Responses:
 		//   200: {"isAuthenticated": bool, "canCreateAdmin": bool}
 		//   500: Internal server error on database access failures (during canCreateAdmin check).
-		se.Router.GET("/api/user/is-authenticated", func(e *core.RequestEvent) error {
+		se.Router.GET(configuration.MountPath(cfg, "/api/user/is-authenticated"), func(e *core.RequestEvent) error {
 			isAuthenticated := e.Auth != nil && e.Auth.Id != ""
 			canCreateAdmin := false
 
-			if !isAuthenticated && cfg.General.InitialAdminRegistration {
+			if !isAuthenticated && live.Load().General.InitialAdminRegistration {
 				exists, err := doesUserExist()
 				if err != nil {
 					return e.Error(
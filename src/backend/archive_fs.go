@@ -0,0 +1,224 @@
+package backend
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// isArchive reports whether name has the extension of an archive openArchive
+// can mount.
+func isArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// openArchive mounts the archive file name read-only. Zip archives are read
+// from disk on demand, tar archives are loaded into memory as they offer no
+// random access. The closer releases the archive file, it may be nil.
+func openArchive(name string) (fs.FS, io.Closer, error) {
+	lower := strings.ToLower(name)
+
+	if strings.HasSuffix(lower, ".zip") {
+		reader, err := zip.OpenReader(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open zip archive '%s': %w", name, err)
+		}
+		return reader, reader, nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open tar archive '%s': %w", name, err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decompress tar archive '%s': %w", name, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	fsys, err := readTarFS(reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read tar archive '%s': %w", name, err)
+	}
+	return fsys, nil, nil
+}
+
+// memFS is a read-only in-memory filesystem.
+type memFS struct {
+	entries map[string]*memFSEntry
+}
+
+type memFSEntry struct {
+	data     []byte
+	info     *memFileInfo
+	children []fs.DirEntry
+}
+
+// readTarFS loads the regular files and directories of a tar stream. Entries
+// with names escaping the root, links and other special files are skipped.
+func readTarFS(r io.Reader) (*memFS, error) {
+	m := &memFS{entries: make(map[string]*memFSEntry)}
+	m.dir(".", time.Time{})
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == "." || !fs.ValidPath(name) {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			m.dir(name, header.ModTime)
+		case tar.TypeReg:
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, err
+			}
+
+			parent := m.dir(path.Dir(name), header.ModTime)
+			if _, ok := m.entries[name]; ok || !parent.info.isDir {
+				continue
+			}
+
+			entry := &memFSEntry{
+				data: data,
+				info: &memFileInfo{
+					name:    path.Base(name),
+					size:    int64(len(data)),
+					mode:    header.FileInfo().Mode().Perm(),
+					modTime: header.ModTime,
+				},
+			}
+			m.entries[name] = entry
+			parent.children = append(parent.children, fs.FileInfoToDirEntry(entry.info))
+		}
+	}
+
+	for _, entry := range m.entries {
+		slices.SortFunc(entry.children, func(a fs.DirEntry, b fs.DirEntry) int {
+			return strings.Compare(a.Name(), b.Name())
+		})
+	}
+
+	return m, nil
+}
+
+// dir returns the directory entry of name, creating it and its parents.
+func (m *memFS) dir(name string, modTime time.Time) *memFSEntry {
+	if entry, ok := m.entries[name]; ok {
+		return entry
+	}
+
+	entry := &memFSEntry{info: &memFileInfo{
+		name:    path.Base(name),
+		mode:    fs.ModeDir | 0o555,
+		modTime: modTime,
+		isDir:   true,
+	}}
+	m.entries[name] = entry
+
+	if name != "." {
+		parent := m.dir(path.Dir(name), modTime)
+		parent.children = append(parent.children, fs.FileInfoToDirEntry(entry.info))
+	}
+	return entry
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entry, ok := m.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if entry.info.isDir {
+		return &memDir{info: entry.info, entries: entry.children}, nil
+	}
+	return newMemFile(entry.data, entry.info), nil
+}
+
+// memDir is an open directory of a memFS.
+type memDir struct {
+	info    *memFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *memDir) Close() error {
+	return nil
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return slices.Clone(remaining), nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(remaining))
+	d.offset += n
+	return slices.Clone(remaining[:n]), nil
+}
+
+// mountFS serves fsys below the directory mount.
+type mountFS struct {
+	fsys  fs.FS
+	mount string
+}
+
+func (m mountFS) Open(name string) (fs.File, error) {
+	if name == m.mount {
+		return m.fsys.Open(".")
+	}
+
+	rest, ok := strings.CutPrefix(name, m.mount+"/")
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return m.fsys.Open(rest)
+}
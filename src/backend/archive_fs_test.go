@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func writeTarGz(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for fileName, content := range files {
+		header := &tar.Header{Name: fileName, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+
+	if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+}
+
+func TestOpenTarArchive(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "docs.tar.gz")
+	writeTarGz(t, name, map[string]string{
+		"./index.html":       "<h1>Docs</h1>",
+		"guide/intro.html":   "intro",
+		"guide/img/logo.svg": "<svg/>",
+		"../escape.txt":      "nope",
+	})
+
+	fsys, closer, err := openArchive(name)
+	if err != nil {
+		t.Fatalf("unexpected error opening archive: %v", err)
+	}
+	if closer != nil {
+		t.Fatal("expected no closer for an in-memory tar archive")
+	}
+
+	if err := fstest.TestFS(fsys, "index.html", "guide/intro.html", "guide/img/logo.svg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(fsys, "escape.txt"); err == nil {
+		t.Fatal("expected entries escaping the root to be skipped")
+	}
+}
+
+func TestMountFS(t *testing.T) {
+	fsys := mountFS{fsys: fstest.MapFS{"index.html": &fstest.MapFile{Data: []byte("docs")}}, mount: "docs/v1"}
+
+	if data, err := fs.ReadFile(fsys, "docs/v1/index.html"); err != nil || string(data) != "docs" {
+		t.Fatalf("expected the mounted file, got %q and error %v", data, err)
+	}
+	if info, err := fs.Stat(fsys, "docs/v1"); err != nil || !info.IsDir() {
+		t.Fatalf("expected the mount point to be a directory, got error %v", err)
+	}
+	if _, err := fs.Stat(fsys, "index.html"); err == nil {
+		t.Fatal("expected files outside the mount point to be missing")
+	}
+}
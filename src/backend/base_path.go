@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+)
+
+// basePathVar is replaced by the base path in HTML files, with its leading
+// and trailing slash.
+const basePathVar = "%APP_BASE_PATH%"
+
+var (
+	htmlTagPattern      = regexp.MustCompile(`<[a-zA-Z][^>]*>`)
+	htmlHeadOpenPattern = regexp.MustCompile(`(?i)<head\b[^>]*>`)
+	htmlBasePattern     = regexp.MustCompile(`(?i)<base\b[^>]*>\s*`)
+
+	// rootRelativeURLPattern matches URL attributes starting with a single
+	// slash, protocol-relative URLs like "//cdn.example.com" are left alone.
+	rootRelativeURLPattern = regexp.MustCompile(`(?i)(\s(?:src|href|action|poster)\s*=\s*["']?)/([^/]|$)`)
+)
+
+// rebaseHTML moves doc below basePath, which has a leading and a trailing
+// slash: root-relative URLs in tag attributes get the base path as prefix,
+// unless they already carry it, and the head gets a matching base element so
+// relative URLs resolve independent of the SPA route.
+func rebaseHTML(doc []byte, basePath string) []byte {
+	doc = htmlTagPattern.ReplaceAllFunc(doc, func(tag []byte) []byte {
+		return rebaseTag(tag, basePath)
+	})
+
+	doc = htmlBasePattern.ReplaceAll(doc, nil)
+
+	loc := htmlHeadOpenPattern.FindIndex(doc)
+	if loc == nil {
+		return doc
+	}
+
+	base := []byte(`<base href="` + html.EscapeString(basePath) + `" />`)
+	result := make([]byte, 0, len(doc)+len(base))
+	result = append(result, doc[:loc[1]]...)
+	result = append(result, base...)
+	result = append(result, doc[loc[1]:]...)
+	return result
+}
+
+func rebaseTag(tag []byte, basePath string) []byte {
+	matches := rootRelativeURLPattern.FindAllSubmatchIndex(tag, -1)
+	if matches == nil {
+		return tag
+	}
+
+	prefix := []byte(basePath[:len(basePath)-1])
+	result := make([]byte, 0, len(tag)+len(matches)*len(prefix))
+	last := 0
+
+	for _, match := range matches {
+		// the first group ends right before the leading slash of the URL
+		slash := match[3]
+		result = append(result, tag[last:slash]...)
+		if !bytes.HasPrefix(tag[slash:], []byte(basePath)) {
+			result = append(result, prefix...)
+		}
+		last = slash
+	}
+
+	return append(result, tag[last:]...)
+}
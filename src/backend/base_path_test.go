@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestRebaseHTML(t *testing.T) {
+	doc := `<html><head lang="en"><base href="/"><link rel="stylesheet" href="/assets/app.css">` +
+		`<script src="/tools/sfs/assets/app.js"></script></head>` +
+		`<body><a href="/">Home</a><a href="//cdn.example.com/x">CDN</a><a href=relative>Rel</a>` +
+		`<script>if (a </ b) location.href = "/x"</script></body></html>`
+
+	expected := `<html><head lang="en"><base href="/tools/sfs/" /><link rel="stylesheet" href="/tools/sfs/assets/app.css">` +
+		`<script src="/tools/sfs/assets/app.js"></script></head>` +
+		`<body><a href="/tools/sfs/">Home</a><a href="//cdn.example.com/x">CDN</a><a href=relative>Rel</a>` +
+		`<script>if (a </ b) location.href = "/x"</script></body></html>`
+
+	if got := string(rebaseHTML([]byte(doc), "/tools/sfs/")); got != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestTransformHTMLUnderBasePath(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{BasePath: "tools/sfs", LiveReload: true}}
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte(`<html><head></head><body><script src="/assets/app.js"></script></body></html>`)},
+	}
+
+	fsList, err := NewFSList(true, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	expected := `<html><head><base href="/tools/sfs/" /></head><body><script src="/tools/sfs/assets/app.js"></script>` +
+		string(liveReloadScript("/tools/sfs"+liveReloadPath)) + `</body></html>`
+	if got := readAll(t, &fsList, "index.html"); got != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestTransformHTMLReplacesBasePathWithoutHTMLVars(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{BasePath: "tools/sfs"}}
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte(`<html><head></head><body><script>window.base = "%APP_BASE_PATH%"</script></body></html>`)},
+	}
+
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	if got := readAll(t, &fsList, "index.html"); !strings.Contains(got, `window.base = "/tools/sfs/"`) {
+		t.Fatalf("expected the base path to be replaced, got %s", got)
+	}
+}
@@ -0,0 +1,174 @@
+package backend
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// hashedAssetMaxAge is the max-age of fingerprinted files: one year, the
+// longest value RFC 9111 caches are expected to honor.
+const hashedAssetMaxAge = 365 * 24 * 60 * 60
+
+// hashedAssetDir is where Vite emits its fingerprinted files, the default
+// build.assetsDir. Files elsewhere, like the ones copied from "public", keep
+// their names across builds.
+const hashedAssetDir = "assets/"
+
+// hashedAssetPattern matches file names carrying a Rollup content hash, which
+// Vite emits as "[name]-[hash].[ext]" with an eight character base64url hash.
+var hashedAssetPattern = regexp.MustCompile(`[-.]([A-Za-z0-9_-]{8})\.[A-Za-z0-9]+$`)
+
+type cacheRule struct {
+	pattern   *regexp.Regexp
+	maxAge    int
+	immutable bool
+	noStore   bool
+}
+
+// cachePolicy decides the Cache-Control header of files served by an FSList.
+type cachePolicy struct {
+	devMode       bool
+	hashedAssets  bool
+	defaultMaxAge int
+	rules         []cacheRule
+}
+
+func newCachePolicy(devMode bool, cfg configuration.StaticCacheConfig) (cachePolicy, error) {
+	policy := cachePolicy{
+		devMode:       devMode,
+		hashedAssets:  cfg.HashedAssets,
+		defaultMaxAge: cfg.DefaultMaxAge,
+		rules:         make([]cacheRule, 0, len(cfg.Rules)),
+	}
+
+	for i, rule := range cfg.Rules {
+		if (rule.Glob == "") == (rule.Regex == "") {
+			return cachePolicy{}, fmt.Errorf("static cache rule %d must set exactly one of 'glob' or 'regex'", i)
+		}
+
+		if rule.MaxAge < 0 {
+			return cachePolicy{}, fmt.Errorf("static cache rule %d has a negative 'maxAge' of %d", i, rule.MaxAge)
+		}
+
+		expr := rule.Regex
+		if rule.Glob != "" {
+			expr = globToRegexp(rule.Glob)
+		}
+
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return cachePolicy{}, fmt.Errorf("static cache rule %d has an invalid pattern: %w", i, err)
+		}
+
+		policy.rules = append(policy.rules, cacheRule{
+			pattern:   pattern,
+			maxAge:    rule.MaxAge,
+			immutable: rule.Immutable,
+			noStore:   rule.NoStore,
+		})
+	}
+
+	return policy, nil
+}
+
+// globToRegexp translates a glob into an anchored regular expression. A single
+// "*" matches within a path segment, "**" matches across segments and "?"
+// matches a single character other than "/".
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	sb.WriteByte('^')
+
+	glob = strings.TrimPrefix(glob, "/")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				// "**/" also matches no directory at all
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteByte('$')
+	return sb.String()
+}
+
+// isHashedAsset reports whether name is in the hashedAssetDir and its base
+// name carries a content hash. Requiring a digit or an upper case letter keeps
+// ordinary names like "my-component.js" from being treated as immutable.
+func isHashedAsset(name string) bool {
+	if !strings.HasPrefix(name, hashedAssetDir) {
+		return false
+	}
+
+	match := hashedAssetPattern.FindStringSubmatch(path.Base(name))
+	if match == nil {
+		return false
+	}
+
+	return strings.ContainsFunc(match[1], func(r rune) bool {
+		return (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z')
+	})
+}
+
+// cacheControl returns the Cache-Control header value for the file name. HTML
+// always has to be revalidated so new deployments are picked up right away,
+// and in dev mode nothing is cached without revalidation. Only files of an
+// immutable filesystem are recognized as hashed assets, files in pb_public
+// can be replaced under the same name.
+func (p cachePolicy) cacheControl(name string, immutable bool) string {
+	name = strings.TrimPrefix(name, "/")
+	isHTML := strings.EqualFold(path.Ext(name), ".html")
+
+	for _, rule := range p.rules {
+		if !rule.pattern.MatchString(name) {
+			continue
+		}
+
+		if rule.noStore {
+			return "no-store"
+		}
+		if isHTML || p.devMode {
+			return "no-cache"
+		}
+		return maxAgeDirective(rule.maxAge, rule.immutable)
+	}
+
+	if isHTML || p.devMode {
+		return "no-cache"
+	}
+
+	if p.hashedAssets && immutable && isHashedAsset(name) {
+		return maxAgeDirective(hashedAssetMaxAge, true)
+	}
+
+	return maxAgeDirective(p.defaultMaxAge, false)
+}
+
+func maxAgeDirective(maxAge int, immutable bool) string {
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+
+	directive := fmt.Sprintf("public, max-age=%d", maxAge)
+	if immutable {
+		directive += ", immutable"
+	}
+	return directive
+}
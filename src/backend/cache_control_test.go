@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestCachePolicyCacheControl(t *testing.T) {
+	policy, err := newCachePolicy(false, configuration.StaticCacheConfig{
+		HashedAssets:  true,
+		DefaultMaxAge: 600,
+		Rules: []configuration.StaticCacheRule{
+			{Glob: "private/**", NoStore: true},
+			{Glob: "fonts/**/*.woff2", MaxAge: 86400, Immutable: true},
+			{Regex: `^docs/.*\.pdf$`, MaxAge: 3600},
+			{Glob: "**/*.html", MaxAge: 3600},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "index.html", expected: "no-cache"},
+		{name: "nested/page.html", expected: "no-cache"},
+		{name: "private/index.html", expected: "no-store"},
+		{name: "private/data.json", expected: "no-store"},
+		{name: "assets/index-BfX3a9Qk.js", expected: "public, max-age=31536000, immutable"},
+		{name: "assets/solid-a1b2c3d4.css", expected: "public, max-age=31536000, immutable"},
+		{name: "assets/my-component.js", expected: "public, max-age=600"},
+		{name: "fonts/inter.woff2", expected: "public, max-age=86400, immutable"},
+		{name: "fonts/inter/bold.woff2", expected: "public, max-age=86400, immutable"},
+		{name: "docs/manual.pdf", expected: "public, max-age=3600"},
+		{name: "favicon.png", expected: "public, max-age=600"},
+		{name: "logo-Standard.svg", expected: "public, max-age=600"},
+		{name: "files/cv-Resume01.pdf", expected: "public, max-age=600"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := policy.cacheControl(tt.name, true)
+			if result != tt.expected {
+				t.Errorf("cacheControl(%q) = %q, expected %q", tt.name, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCachePolicyIgnoresHashesOfMutableFiles(t *testing.T) {
+	policy, err := newCachePolicy(false, configuration.StaticCacheConfig{HashedAssets: true, DefaultMaxAge: 600})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// pb_public files can be edited in place under their name
+	if result := policy.cacheControl("assets/logo-Standard.svg", false); result != "public, max-age=600" {
+		t.Fatalf("expected mutable files to use the default max-age, got %q", result)
+	}
+}
+
+func TestCachePolicyDevModeRevalidates(t *testing.T) {
+	policy, err := newCachePolicy(true, configuration.StaticCacheConfig{HashedAssets: true, DefaultMaxAge: 600})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result := policy.cacheControl("assets/index-BfX3a9Qk.js", true); result != "no-cache" {
+		t.Fatalf("expected dev mode to revalidate hashed assets, got %q", result)
+	}
+}
+
+func TestCachePolicyRejectsInvalidRules(t *testing.T) {
+	rules := []configuration.StaticCacheRule{
+		{},
+		{Glob: "*.js", Regex: `\.js$`},
+		{Regex: "("},
+		{Glob: "*.js", MaxAge: -1},
+	}
+
+	for _, rule := range rules {
+		_, err := newCachePolicy(false, configuration.StaticCacheConfig{Rules: []configuration.StaticCacheRule{rule}})
+		if err == nil {
+			t.Errorf("expected rule %+v to be rejected", rule)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package backend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// encodingPreference lists the supported content codings in the order the
+// server prefers them, together with the file extension of their precompressed
+// siblings (e.g. "index.js.br" as emitted by vite-plugin-compression).
+var encodingPreference = []struct {
+	coding    string
+	extension string
+}{
+	{coding: "br", extension: ".br"},
+	{coding: "gzip", extension: ".gz"},
+}
+
+// compressibleExtensions are the file types for which precompressed variants
+// are looked up and negotiated. It mirrors the filter of the compression plugin
+// in vite.config.ts plus a few text formats commonly dropped into pb_public.
+var compressibleExtensions = map[string]bool{
+	".css":         true,
+	".html":        true,
+	".js":          true,
+	".json":        true,
+	".map":         true,
+	".mjs":         true,
+	".svg":         true,
+	".ttf":         true,
+	".txt":         true,
+	".wasm":        true,
+	".webmanifest": true,
+	".woff":        true,
+	".woff2":       true,
+	".xml":         true,
+}
+
+// maxCompressedFileSize is the size up to which immutable files without a
+// ".gz" sibling are compressed in memory. The compressed data stays in the
+// cache, larger files are served as they are.
+const maxCompressedFileSize = 8 << 20
+
+// encodedVariant is a precompressed representation of a file. It either refers
+// to a sibling file within the same filesystem or holds the compressed bytes.
+type encodedVariant struct {
+	name    string
+	content []byte
+	info    fs.FileInfo
+}
+
+func isCompressible(name string) bool {
+	return compressibleExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// representation is a file picked to answer a request together with the
+// metadata needed to describe it in response headers.
+type representation struct {
+	file     fs.File
+	name     string
+	item     FSCacheItem
+	encoding string
+
+	// fallback is set if the SPA index is served in place of a missing file.
+	fallback bool
+}
+
+func (f *FSList) openRepresentation(name string, acceptEncoding string) (representation, error) {
+	item, file, err := f.resolve(name)
+	if err != nil {
+		return representation{}, err
+	}
+
+	rep := representation{name: name, item: item}
+
+	encoding := ""
+	var variant encodedVariant
+	if acceptEncoding != "" && isCompressible(name) {
+		variants := f.encodedVariants(name, item, file)
+		encoding = negotiateEncoding(acceptEncoding, variants)
+		variant = variants[encoding]
+	}
+
+	if encoding == "" {
+		if file == nil {
+			file, err = item.open(name)
+			if err != nil {
+				return representation{}, err
+			}
+		}
+		rep.file = file
+		return rep, nil
+	}
+
+	if file != nil {
+		file.Close()
+	}
+
+	rep.encoding = encoding
+	if variant.content != nil {
+		rep.file = newMemFile(variant.content, variant.info)
+		return rep, nil
+	}
+
+	encodedFile, err := item.fs.Open(variant.name)
+	if err != nil {
+		return representation{}, err
+	}
+
+	rep.file = &variantFile{File: encodedFile, name: variant.name, info: variant.info}
+	return rep, nil
+}
+
+// encodedVariants returns the precompressed representations of an item. HTML
+// that went through transformHTMLFile is compressed in memory because its
+// siblings still contain the raw placeholders. For immutable items the result
+// is stored in the cache, together with gzip data built from the original if
+// the filesystem does not ship a ".gz" sibling.
+func (f *FSList) encodedVariants(name string, item FSCacheItem, file fs.File) map[string]encodedVariant {
+	if item.encoded != nil {
+		return item.encoded
+	}
+
+	variants := make(map[string]encodedVariant)
+
+	if item.content != nil {
+		if compressed, ok := gzipContent(bytes.NewReader(item.content), int64(len(item.content))); ok {
+			variants["gzip"] = encodedVariant{
+				content: compressed,
+				info:    cloneFileInfo(name, item.info, int64(len(compressed))),
+			}
+		}
+	} else {
+		var info fs.FileInfo
+		var err error
+		if file != nil {
+			info, err = file.Stat()
+		} else {
+			info, err = fs.Stat(item.fs, name)
+		}
+		if err != nil || info.IsDir() {
+			return variants
+		}
+
+		for _, pref := range encodingPreference {
+			siblingInfo, err := fs.Stat(item.fs, name+pref.extension)
+			if err != nil || siblingInfo.IsDir() {
+				continue
+			}
+
+			// A sibling older than the original is a leftover from a previous build.
+			if siblingInfo.ModTime().Before(info.ModTime()) {
+				continue
+			}
+
+			variants[pref.coding] = encodedVariant{
+				name: name + pref.extension,
+				info: cloneFileInfo(name, info, siblingInfo.Size()),
+			}
+		}
+
+		if _, ok := variants["gzip"]; !ok && item.immutable && info.Size() <= maxCompressedFileSize {
+			if compressed, ok := gzipFile(item.fs, name, info.Size()); ok {
+				variants["gzip"] = encodedVariant{
+					content: compressed,
+					info:    cloneFileInfo(name, info, int64(len(compressed))),
+				}
+			}
+		}
+	}
+
+	if item.immutable {
+		f.updateCached(name, func(cached *FSCacheItem) {
+			cached.encoded = variants
+		})
+	}
+
+	return variants
+}
+
+// gzipFile compresses the file name of fsys, see gzipContent.
+func gzipFile(fsys fs.FS, name string, size int64) ([]byte, bool) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	return gzipContent(file, size)
+}
+
+// gzipContent compresses the size bytes read from r and reports whether doing
+// so saved any bytes.
+func gzipContent(r io.Reader, size int64) ([]byte, bool) {
+	var buf bytes.Buffer
+
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, false
+	}
+
+	if _, err := io.Copy(writer, r); err != nil {
+		return nil, false
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, false
+	}
+
+	if int64(buf.Len()) >= size {
+		return nil, false
+	}
+
+	return buf.Bytes(), true
+}
+
+// negotiateEncoding picks the available content coding with the highest
+// quality value in acceptEncoding. Ties are broken by encodingPreference.
+func negotiateEncoding(acceptEncoding string, available map[string]encodedVariant) string {
+	if acceptEncoding == "" || len(available) == 0 {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	wildcard := 0.0
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "x-gzip" {
+			coding = "gzip"
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(param, "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				quality = parsed
+			}
+		}
+
+		if coding == "*" {
+			wildcard = quality
+			continue
+		}
+		qualities[coding] = quality
+	}
+
+	best := ""
+	bestQuality := 0.0
+	for _, pref := range encodingPreference {
+		if _, ok := available[pref.coding]; !ok {
+			continue
+		}
+
+		quality, ok := qualities[pref.coding]
+		if !ok {
+			quality = wildcard
+		}
+
+		if quality > bestQuality {
+			best = pref.coding
+			bestQuality = quality
+		}
+	}
+
+	return best
+}
+
+// variantFile serves a precompressed sibling under the file info of the
+// original, so the content type is still derived from the original name.
+type variantFile struct {
+	fs.File
+	name string
+	info fs.FileInfo
+}
+
+func (v *variantFile) Stat() (fs.FileInfo, error) {
+	return v.info, nil
+}
+
+func (v *variantFile) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := v.File.(io.Seeker)
+	if !ok {
+		return 0, errors.New("precompressed file does not implement io.Seeker")
+	}
+	return seeker.Seek(offset, whence)
+}
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// reloadConfig reloads the app config and logs the outcome, a config that
+// fails to load or validate is logged and the previous one kept.
+func reloadConfig(trigger string) {
+	result, err := configuration.Reload()
+	if err != nil {
+		log.Printf("Failed to reload the config on %s, keeping the previous one: %v\n", trigger, err)
+		return
+	}
+	log.Printf("Reloaded the config on %s: %s.\n", trigger, result)
+	for _, problem := range result.Warnings {
+		log.Printf("Config %s\n", problem)
+	}
+}
+
+// watchConfigReload reloads the app config on SIGHUP and, if watchFiles is
+// set, when one of its files changes, until ctx is done.
+func watchConfigReload(ctx context.Context, watchFiles bool) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	var files []string
+	states := make(map[string]fileState)
+	if watchFiles {
+		var err error
+		files, err = configuration.Files()
+		if err != nil {
+			log.Printf("Failed to watch the config files: %v\n", err)
+		}
+		for _, name := range files {
+			states[name] = statConfigFile(name)
+		}
+	}
+
+	go func() {
+		defer signal.Stop(hangup)
+
+		// a nil channel never fires, so nothing is polled without files
+		var poll <-chan time.Time
+		if len(files) > 0 {
+			ticker := time.NewTicker(watchPollInterval)
+			defer ticker.Stop()
+			poll = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				reloadConfig("SIGHUP")
+			case <-poll:
+				var changed bool
+				for _, name := range files {
+					state := statConfigFile(name)
+					if state != states[name] {
+						states[name] = state
+						changed = true
+					}
+				}
+				if !changed {
+					continue
+				}
+
+				reloadConfig("file change")
+
+				// the reload may have upgraded the file itself
+				for _, name := range files {
+					states[name] = statConfigFile(name)
+				}
+			}
+		}
+	}()
+}
+
+// statConfigFile returns the state of a config file, the zero state if it is
+// missing.
+func statConfigFile(name string) fileState {
+	info, err := os.Stat(name)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// reloadableCORS replaces the CORS middleware of PocketBase, which is set up
+// once with the origins of the --origins flag, by one following the allowed
+// origins of the reloaded config.
+type reloadableCORS struct {
+	handler atomic.Pointer[hook.Handler[*core.RequestEvent]]
+}
+
+func newReloadableCORS(origins []string) *reloadableCORS {
+	c := &reloadableCORS{}
+	c.set(origins)
+	return c
+}
+
+func (c *reloadableCORS) set(origins []string) {
+	// like the --origins flag, which defaults to any origin
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+
+	c.handler.Store(apis.CORS(apis.CORSConfig{
+		AllowOrigins: origins,
+		AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+	}))
+}
+
+// bind replaces the CORS middleware of the router, keeping its id and
+// priority.
+func (c *reloadableCORS) bind(se *core.ServeEvent) {
+	se.Router.Unbind(apis.DefaultCorsMiddlewareId)
+	se.Router.Bind(&hook.Handler[*core.RequestEvent]{
+		Id:       apis.DefaultCorsMiddlewareId,
+		Priority: apis.DefaultCorsMiddlewarePriority,
+		Func: func(e *core.RequestEvent) error {
+			return c.handler.Load().Func(e)
+		},
+	})
+}
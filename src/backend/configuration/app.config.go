@@ -2,34 +2,59 @@ package configuration
 
 // AppConfig mirrors the structure of app.config.jsonc at the project root.
 type AppConfig struct {
-	General GeneralConfig `json:"general"`
-	Server  ServerConfig  `json:"server"`
+	// ConfigVersion is the version of the config file layout.
+	ConfigVersion int           `json:"configVersion" env-description:"The version of the config file layout."`
+	General       GeneralConfig `json:"general"`
+	Server        ServerConfig  `json:"server"`
 }
 
 // GeneralConfig holds general application metadata.
 type GeneralConfig struct {
-	Name                     string `json:"name" env:"APP_GENERAL_NAME" env-description:"The application name."`
-	Description              string `json:"description" env:"APP_GENERAL_DESCRIPTION" env-description:"A brief description of the application."`
-	Version                  string `json:"version" env:"APP_GENERAL_VERSION" env-description:"The current version of the application."`
-	URL                      string `json:"url" env:"APP_GENERAL_URL" env-description:"The URL this application is hosted at."`
-	InitialAdminRegistration bool   `json:"initialAdminRegistration" env:"APP_GENERAL_INITIAL_ADMIN_REGISTRATION" env-default:"false" env-description:"Enable the initial admin user registration form."`
-	Debug                    bool   `json:"debug" env:"APP_GENERAL_DEBUG" env-default:"false" env-description:"Enable debug mode to print configuration and environment variables on startup."`
+	Name                     string `json:"name" env:"APP_GENERAL_NAME" public:"true" reload:"live" env-description:"The application name."`
+	Description              string `json:"description" env:"APP_GENERAL_DESCRIPTION" public:"true" reload:"live" env-description:"A brief description of the application."`
+	Version                  string `json:"version" env:"APP_GENERAL_VERSION" public:"true" reload:"live" env-description:"The current version of the application."`
+	URL                      string `json:"url" env:"APP_GENERAL_URL" public:"true" reload:"live" env-description:"The URL this application is hosted at."`
+	InitialAdminRegistration bool   `json:"initialAdminRegistration" env:"APP_GENERAL_INITIAL_ADMIN_REGISTRATION" reload:"live" env-default:"false" env-description:"Enable the initial admin user registration form."`
+	Debug                    bool   `json:"debug" env:"APP_GENERAL_DEBUG" reload:"live" env-default:"false" env-description:"Enable debug mode to print configuration and environment variables on startup."`
 }
 
 // ServerConfig groups server-specific settings.
 type ServerConfig struct {
-	HTTP     HTTPConfig     `json:"http"`
-	HTTPS    HTTPSConfig    `json:"https"`
-	Email    EmailConfig    `json:"email"`
-	Database DatabaseConfig `json:"database"`
+	HTTP        HTTPConfig        `json:"http"`
+	HTTPS       HTTPSConfig       `json:"https"`
+	Email       EmailConfig       `json:"email"`
+	Database    DatabaseConfig    `json:"database"`
+	StaticCache StaticCacheConfig `json:"staticCache"`
+	FileCache   FileCacheConfig   `json:"fileCache"`
+	Security    SecurityConfig    `json:"security"`
+	Maintenance MaintenanceConfig `json:"maintenance"`
 
 	EncryptionKey             *string  `json:"encryptionKey" env:"APP_SERVER_ENCRYPTION_KEY" env-description:"An encryption key with a length of 32 characters used to encrypt app settings."`
 	Domains                   []string `json:"domains" env:"APP_SERVER_DOMAINS" env-description:"Comma-separated list of domains for issuing Let's Encrypt certificates." env-separator:","`
-	AllowedOrigins            []string `json:"allowedOrigins" env:"APP_SERVER_ALLOWED_ORIGINS" env-default:"*" env-description:"Comma-separated list of CORS allowed domain origins." env-separator:","`
+	AllowedOrigins            []string `json:"allowedOrigins" env:"APP_SERVER_ALLOWED_ORIGINS" reload:"live" env-default:"*" env-description:"Comma-separated list of CORS allowed domain origins." env-separator:","`
 	ForceDevMode              bool     `json:"forceDevMode" env:"APP_SERVER_FORCE_DEV_MODE" env-default:"false" env-description:"Force the application to run in development mode."`
 	IndexFallback             bool     `json:"indexFallback" env:"APP_SERVER_INDEX_FALLBACK" env-default:"true" env-description:"Enable SPA index fallback for unknown routes."`
+	IndexFallbackRoutes       []string `json:"indexFallbackRoutes" env:"APP_SERVER_INDEX_FALLBACK_ROUTES" env-description:"Comma-separated list of path prefixes that always fall back to the SPA index, even if they look like a file name." env-separator:","`
+	IndexFallbackExclude      []string `json:"indexFallbackExclude" env:"APP_SERVER_INDEX_FALLBACK_EXCLUDE" env-default:"/api/" env-description:"Comma-separated list of path prefixes that never fall back to the SPA index." env-separator:","`
+	NotFoundPage              string   `json:"notFoundPage" env:"APP_SERVER_NOT_FOUND_PAGE" env-default:"404.html" env-description:"The static file served with status 404 for missing files that do not fall back to the SPA index."`
+	RouteManifest             string   `json:"routeManifest" env:"APP_SERVER_ROUTE_MANIFEST" env-default:"routes.json" env-description:"The route manifest emitted by the frontend build. SPA index fallbacks for paths matching none of its routes get status 404, empty to disable."`
 	StaticFileServerImmutable bool     `json:"staticFileServerImmutable" env:"APP_SERVER_STATIC_FILE_SERVER_IMMUTABLE" env-default:"true" env-description:"Enable immutable caching for static file server."`
-	ReplaceHTMLVars           bool     `json:"replaceHTMLVars" env:"APP_SERVER_REPLACE_HTML_VARS" env-default:"true" env-description:"Enable replacing HTML variables in served HTML files."`
+	PreloadStatic             bool     `json:"preloadStatic" env:"APP_SERVER_PRELOAD_STATIC" env-default:"false" env-description:"Load the embedded frontend bundle into the file cache at startup, including transformed HTML and precompressed variants (production mode only)."`
+	ReplaceHTMLVars           bool     `json:"replaceHTMLVars" env:"APP_SERVER_REPLACE_HTML_VARS" env-default:"true" env-description:"Enable replacing the '%APP_CONFIG_*%' variables of the public config values and '%APP_BASE_PATH%' in served HTML files."`
+	InjectPublicConfig        bool     `json:"injectPublicConfig" env:"APP_SERVER_INJECT_PUBLIC_CONFIG" env-default:"true" env-description:"Set 'window.__APP_CONFIG__' to the public config values in served HTML files."`
+	LiveReload                bool     `json:"liveReload" env:"APP_SERVER_LIVE_RELOAD" env-default:"true" env-description:"Reload connected browsers when files in 'dist' or 'pb_public' change (development mode only)."`
+	ViteDevServerURL          string   `json:"viteDevServerURL" env:"APP_SERVER_VITE_DEV_SERVER_URL" env-default:"" env-description:"Proxy the frontend to this Vite dev server instead of serving 'dist', e.g. 'http://localhost:5173' (development mode only)."`
+	BasePath                  string   `json:"basePath" env:"APP_SERVER_BASE_PATH" env-default:"/" env-description:"The URL path prefix the app is served under, e.g. '/tools/sfs/'. It applies to the static files and the custom API routes, the PocketBase API and dashboard stay at the root."`
+
+	VirtualHosts []VirtualHostConfig `json:"virtualHosts"`
+	UnknownHost  string              `json:"unknownHost" env:"APP_SERVER_UNKNOWN_HOST" env-default:"default" env-description:"How static files are served to hosts without a virtual host: 'default' serves the app bundle, 'reject' answers with status 421, any other value names a host of the virtual host to serve."`
+
+	StaticLayers []StaticLayerConfig `json:"staticLayers"`
+
+	Redirects     []RedirectRule `json:"redirects"`
+	RedirectsFile string         `json:"redirectsFile" env:"APP_SERVER_REDIRECTS_FILE" env-default:"_redirects" env-description:"A Netlify-style redirects file in 'dist' or 'pb_public', its rules are evaluated after the configured redirects and the file is not served, empty to disable."`
+
+	WatchConfig bool `json:"watchConfig" env:"APP_SERVER_WATCH_CONFIG" env-default:"false" env-description:"Reload the configuration when 'app.config.jsonc' or '.env' in 'pb_data' change. It is also reloaded on SIGHUP and through the superuser API."`
 }
 
 // HTTPConfig holds HTTP server settings.
@@ -56,3 +81,101 @@ type EmailConfig struct {
 type DatabaseConfig struct {
 	QueryTimeoutSeconds int `json:"queryTimeoutSeconds" env:"APP_SERVER_DATABASE_QUERY_TIMEOUT_SECONDS" env-default:"30" env-description:"The default SELECT queries timeout in seconds."`
 }
+
+// StaticCacheConfig holds the Cache-Control policy of the static file server.
+type StaticCacheConfig struct {
+	HashedAssets  bool              `json:"hashedAssets" env:"APP_SERVER_STATIC_CACHE_HASHED_ASSETS" env-default:"true" env-description:"Serve fingerprinted file names in the 'assets' directory of the embedded 'dist', like 'index-BfX3a9Qk.js', with a year-long immutable caching policy."`
+	DefaultMaxAge int               `json:"defaultMaxAge" env:"APP_SERVER_STATIC_CACHE_DEFAULT_MAX_AGE" env-default:"0" env-description:"The max-age in seconds for static files not matched by any rule."`
+	Rules         []StaticCacheRule `json:"rules"`
+}
+
+// FileCacheConfig bounds the in-memory cache of the static file server.
+type FileCacheConfig struct {
+	MaxEntries         int `json:"maxEntries" env:"APP_SERVER_FILE_CACHE_MAX_ENTRIES" env-default:"4096" env-description:"The maximum number of cached static files, 0 for no limit."`
+	MaxMemoryMB        int `json:"maxMemoryMB" env:"APP_SERVER_FILE_CACHE_MAX_MEMORY_MB" env-default:"64" env-description:"The maximum memory in megabytes used by cached static file contents, 0 for no limit."`
+	NegativeMaxEntries int `json:"negativeMaxEntries" env:"APP_SERVER_FILE_CACHE_NEGATIVE_MAX_ENTRIES" env-default:"1024" env-description:"The maximum number of cached lookups of missing files, 0 for no limit."`
+	NegativeTTLSeconds int `json:"negativeTTLSeconds" env:"APP_SERVER_FILE_CACHE_NEGATIVE_TTL_SECONDS" env-default:"30" env-description:"How long lookups of missing files are cached in seconds, 0 to keep them until evicted."`
+}
+
+// SecurityConfig holds the security headers of the server responses.
+type SecurityConfig struct {
+	CSP                   bool     `json:"csp" env:"APP_SERVER_SECURITY_CSP" env-default:"true" env-description:"Send a Content-Security-Policy with served HTML files and add a per-response nonce to their script and style elements."`
+	CSPReportOnly         bool     `json:"cspReportOnly" env:"APP_SERVER_SECURITY_CSP_REPORT_ONLY" env-default:"false" env-description:"Only report Content-Security-Policy violations instead of enforcing the policy."`
+	CSPDirectives         []string `json:"cspDirectives" env:"APP_SERVER_SECURITY_CSP_DIRECTIVES" env-default:"default-src 'self';script-src 'self';style-src 'self' 'unsafe-inline';img-src 'self' data: blob:;font-src 'self' data:;object-src 'none';base-uri 'self';frame-ancestors 'self'" env-description:"Semicolon-separated Content-Security-Policy directives. The nonce is added to 'script-src' and to 'style-src' unless it allows 'unsafe-inline'." env-separator:";"`
+	SubresourceIntegrity  bool     `json:"subresourceIntegrity" env:"APP_SERVER_SECURITY_SUBRESOURCE_INTEGRITY" env-default:"true" env-description:"Add integrity and crossorigin attributes to the script and stylesheet elements of served HTML files whose assets are served from an immutable filesystem."`
+	HSTSMaxAge            int      `json:"hstsMaxAge" env:"APP_SERVER_SECURITY_HSTS_MAX_AGE" env-default:"0" env-description:"The max-age in seconds of the Strict-Transport-Security header sent over HTTPS, 0 to disable it."`
+	HSTSIncludeSubdomains bool     `json:"hstsIncludeSubdomains" env:"APP_SERVER_SECURITY_HSTS_INCLUDE_SUBDOMAINS" env-default:"false" env-description:"Extend Strict-Transport-Security to all subdomains."`
+	ContentTypeNosniff    bool     `json:"contentTypeNosniff" env:"APP_SERVER_SECURITY_CONTENT_TYPE_NOSNIFF" env-default:"true" env-description:"Send 'X-Content-Type-Options: nosniff'."`
+	ReferrerPolicy        string   `json:"referrerPolicy" env:"APP_SERVER_SECURITY_REFERRER_POLICY" env-default:"strict-origin-when-cross-origin" env-description:"The Referrer-Policy header, empty to omit it."`
+	PermissionsPolicy     string   `json:"permissionsPolicy" env:"APP_SERVER_SECURITY_PERMISSIONS_POLICY" env-default:"camera=(), microphone=(), geolocation=()" env-description:"The Permissions-Policy header, empty to omit it."`
+}
+
+// MaintenanceConfig holds the maintenance mode settings. Superusers can also
+// toggle the mode at runtime, that state is stored in the PocketBase settings.
+type MaintenanceConfig struct {
+	Enabled           bool     `json:"enabled" env:"APP_SERVER_MAINTENANCE_ENABLED" env-default:"false" env-description:"Enable the maintenance mode, regardless of the state toggled by superusers."`
+	Page              string   `json:"page" env:"APP_SERVER_MAINTENANCE_PAGE" env-default:"maintenance.html" env-description:"The static file served with status 503 instead of the app during maintenance."`
+	RetryAfterSeconds int      `json:"retryAfterSeconds" env:"APP_SERVER_MAINTENANCE_RETRY_AFTER_SECONDS" env-default:"300" env-description:"The Retry-After header of responses during maintenance in seconds, 0 to omit it."`
+	AllowedIPs        []string `json:"allowedIPs" env:"APP_SERVER_MAINTENANCE_ALLOWED_IPS" env-description:"Comma-separated list of client IPs or CIDR ranges that see the normal app during maintenance." env-separator:","`
+	BypassToken       string   `json:"bypassToken" env:"APP_SERVER_MAINTENANCE_BYPASS_TOKEN" env-description:"Opening any page with '?maintenance_bypass=<token>' sets a cookie that bypasses the maintenance mode, empty to disable."`
+}
+
+// StaticLayerConfig adds a read-only filesystem to the static file stack.
+// Archives are reloaded when a new file is moved over them, so frontend
+// updates can be shipped without rebuilding the binary.
+type StaticLayerConfig struct {
+	// Path is a ".zip", ".tar", ".tar.gz" or ".tgz" archive or a directory.
+	Path string `json:"path"`
+	// Mount is the path the layer is served below, e.g. "docs", empty for
+	// the root.
+	Mount string `json:"mount"`
+	// Priority orders the layers, the app bundle has priority 0. Files of
+	// layers with a higher priority shadow the ones of lower priorities,
+	// equal priorities keep their order after the app bundle.
+	Priority int `json:"priority"`
+	// Immutable caches the files of the layer like the embedded bundle, even
+	// in development mode. In production mode all layers are immutable if
+	// StaticFileServerImmutable is set.
+	Immutable bool `json:"immutable"`
+}
+
+// VirtualHostConfig serves the static files of its hosts from a stack of their
+// own. Unset optional fields inherit the server wide setting.
+type VirtualHostConfig struct {
+	// Hosts are host names like "www.example.com", "*.example.com" matches all
+	// subdomains of example.com.
+	Hosts []string `json:"hosts"`
+	// Root is the directory served to the hosts, e.g. "pb_public/www". Empty
+	// serves the app bundle like the default stack.
+	Root string `json:"root"`
+	// HTMLVars adds or overrides HTML variables, keyed without the percent
+	// signs, e.g. "APP_CONFIG_GENERAL_NAME".
+	HTMLVars      map[string]string  `json:"htmlVars"`
+	IndexFallback *bool              `json:"indexFallback"`
+	NotFoundPage  *string            `json:"notFoundPage"`
+	StaticCache   *StaticCacheConfig `json:"staticCache"`
+}
+
+// RedirectRule redirects or rewrites static file requests whose path matches
+// From. Rules are evaluated in order, before the static files are looked up.
+type RedirectRule struct {
+	// From is a path like "/blog/:year/:slug" or "/docs/*", where ":name"
+	// matches one segment and a trailing "*" all remaining ones.
+	From string `json:"from"`
+	// To is the target path or absolute URL, it may use the placeholders of
+	// From and ":splat" for the segments matched by "*".
+	To string `json:"to"`
+	// Status is 301, 302, 307 or 308 for a redirect and 200 to serve To
+	// instead, 0 defaults to 301.
+	Status int `json:"status"`
+}
+
+// StaticCacheRule sets the Cache-Control policy for static files whose path
+// matches either Glob or Regex. Rules are evaluated in order.
+type StaticCacheRule struct {
+	Glob      string `json:"glob"`
+	Regex     string `json:"regex"`
+	MaxAge    int    `json:"maxAge"`
+	Immutable bool   `json:"immutable"`
+	NoStore   bool   `json:"noStore"`
+}
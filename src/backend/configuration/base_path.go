@@ -0,0 +1,50 @@
+package configuration
+
+import (
+	"path"
+	"strings"
+)
+
+// NormalizeBasePath returns basePath with a leading and a trailing slash, "/"
+// for an empty base path.
+func NormalizeBasePath(basePath string) string {
+	basePath = path.Clean("/" + strings.TrimSpace(basePath))
+	if basePath == "/" {
+		return basePath
+	}
+	return basePath + "/"
+}
+
+// MountPath returns the absolute route mounted below the configured base path,
+// e.g. "/tools/sfs/api/user/exists" for "/api/user/exists".
+func MountPath(cfg AppConfig, route string) string {
+	return strings.TrimSuffix(NormalizeBasePath(cfg.Server.BasePath), "/") + route
+}
+
+// MountPoint is a route prefix served by the app.
+type MountPoint struct {
+	Path        string
+	Description string
+}
+
+// MountPoints lists where the app serves its routes with the configured base
+// path applied. The live reload route is only mounted in development mode,
+// as reported by isDev.
+func MountPoints(cfg AppConfig, isDev bool) []MountPoint {
+	mountPoints := []MountPoint{
+		{Path: MountPath(cfg, "/"), Description: "Static files and SPA"},
+		{Path: MountPath(cfg, "/api/user/"), Description: "User API"},
+		{Path: MountPath(cfg, "/api/config/public"), Description: "Public config API"},
+		{Path: MountPath(cfg, "/api/config/reload"), Description: "Config reload"},
+		{Path: MountPath(cfg, "/api/static/cache-stats"), Description: "Static file cache stats"},
+		{Path: MountPath(cfg, "/api/maintenance"), Description: "Maintenance mode"},
+	}
+	if isDev && cfg.Server.LiveReload {
+		mountPoints = append(mountPoints, MountPoint{Path: MountPath(cfg, "/api/dev/live-reload"), Description: "Live reload"})
+	}
+
+	return append(mountPoints,
+		MountPoint{Path: "/api/", Description: "PocketBase API"},
+		MountPoint{Path: "/_/", Description: "PocketBase dashboard"},
+	)
+}
@@ -0,0 +1,45 @@
+package configuration
+
+import "testing"
+
+func TestNormalizeBasePath(t *testing.T) {
+	for basePath, expected := range map[string]string{
+		"":              "/",
+		"/":             "/",
+		"tools/sfs":     "/tools/sfs/",
+		"/tools/sfs/":   "/tools/sfs/",
+		" /tools//sfs ": "/tools/sfs/",
+	} {
+		if got := NormalizeBasePath(basePath); got != expected {
+			t.Errorf("NormalizeBasePath(%q): expected %q, got %q", basePath, expected, got)
+		}
+	}
+
+	cfg := AppConfig{Server: ServerConfig{BasePath: "/tools/sfs/"}}
+	if got := MountPath(cfg, "/api/user/exists"); got != "/tools/sfs/api/user/exists" {
+		t.Fatalf("expected the route below the base path, got %q", got)
+	}
+	if got := MountPath(AppConfig{}, "/{path...}"); got != "/{path...}" {
+		t.Fatalf("expected the route at the root, got %q", got)
+	}
+}
+
+func TestMountPointsListLiveReloadOnlyInDevMode(t *testing.T) {
+	cfg := AppConfig{Server: ServerConfig{BasePath: "/tools/", LiveReload: true}}
+
+	hasLiveReload := func(isDev bool) bool {
+		for _, mountPoint := range MountPoints(cfg, isDev) {
+			if mountPoint.Path == "/tools/api/dev/live-reload" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasLiveReload(false) {
+		t.Error("expected no live reload route outside of development mode")
+	}
+	if !hasLiveReload(true) {
+		t.Error("expected the live reload route in development mode")
+	}
+}
@@ -5,11 +5,38 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 )
 
+type debugSection struct {
+	title string
+	print func(w io.Writer)
+}
+
+var (
+	debugSections      []debugSection
+	debugSectionsMutex sync.Mutex
+)
+
+// SetDebugSection adds a section to the output of DebugPrint, replacing an
+// earlier section with the same title. Other packages use it to report state
+// that is only known after startup, e.g. the preloaded static files.
+func SetDebugSection(title string, print func(w io.Writer)) {
+	debugSectionsMutex.Lock()
+	defer debugSectionsMutex.Unlock()
+
+	for i := range debugSections {
+		if debugSections[i].title == title {
+			debugSections[i].print = print
+			return
+		}
+	}
+	debugSections = append(debugSections, debugSection{title: title, print: print})
+}
+
 // DebugPrint prints the current configuration and environment variables to the given writer.
-// If w is nil, it prints to os.Stdout.
-func DebugPrint(w io.Writer) error {
+// If w is nil, it prints to os.Stdout. isDev tells whether the app runs in development mode.
+func DebugPrint(w io.Writer, isDev bool) error {
 	if w == nil {
 		w = os.Stdout
 	}
@@ -97,6 +124,19 @@ func DebugPrint(w io.Writer) error {
 	fmt.Fprintf(w, "  HTTPS Server: %s:%d (enabled: %v)\n",
 		cfg.Server.HTTPS.Address, cfg.Server.HTTPS.Port, cfg.Server.HTTPS.Enabled)
 	fmt.Fprintf(w, "  Force Dev:    %v\n", cfg.Server.ForceDevMode)
+	fmt.Fprintf(w, "  Base Path:    %s\n", NormalizeBasePath(cfg.Server.BasePath))
+	fmt.Fprintln(w, "")
+
+	fmt.Fprintln(w, "--- Validation ---")
+	fmt.Fprintln(w, "")
+	problems := cfg.Validate()
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Fprintf(w, "  %s\n", problem)
+		}
+	} else {
+		fmt.Fprintln(w, "  (no problems)")
+	}
 	fmt.Fprintln(w, "")
 
 	fmt.Fprintln(w, "--- Generated CLI Arguments ---")
@@ -109,6 +149,22 @@ func DebugPrint(w io.Writer) error {
 	}
 	fmt.Fprintln(w, "")
 
+	fmt.Fprintln(w, "--- Mount Points ---")
+	fmt.Fprintln(w, "")
+	for _, mountPoint := range MountPoints(cfg, isDev) {
+		fmt.Fprintf(w, "  %-32s %s\n", mountPoint.Path, mountPoint.Description)
+	}
+	fmt.Fprintln(w, "")
+
+	debugSectionsMutex.Lock()
+	for _, section := range debugSections {
+		fmt.Fprintf(w, "--- %s ---\n", section.title)
+		fmt.Fprintln(w, "")
+		section.print(w)
+		fmt.Fprintln(w, "")
+	}
+	debugSectionsMutex.Unlock()
+
 	fmt.Fprintln(w, "--- Effective os.Args ---")
 	fmt.Fprintln(w, "")
 	fmt.Fprintf(w, "  %s\n", strings.Join(os.Args, " "))
@@ -122,7 +178,7 @@ func DebugPrint(w io.Writer) error {
 
 // DebugPrintIfEnabled prints debug information only if debug mode is enabled in the config.
 // Returns true if debug output was printed.
-func DebugPrintIfEnabled(w io.Writer) (bool, error) {
+func DebugPrintIfEnabled(w io.Writer, isDev bool) (bool, error) {
 	cfg, err := Get()
 	if err != nil {
 		return false, fmt.Errorf("failed to load config for debug check: %w", err)
@@ -132,7 +188,7 @@ func DebugPrintIfEnabled(w io.Writer) (bool, error) {
 		return false, nil
 	}
 
-	err = DebugPrint(w)
+	err = DebugPrint(w, isDev)
 	if err != nil {
 		return false, err
 	}
@@ -3,15 +3,17 @@ package configuration
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
-	"github.com/komkom/jsonc/jsonc"
 	"github.com/yerTools/simple-frontend-stack/config"
 )
 
@@ -21,7 +23,14 @@ var (
 	appConfigMutex  sync.Mutex
 )
 
+// ParseAppConfig parses the sanitized JSON of an app.config.jsonc. Unknown
+// keys and values of the wrong type are rejected with their path, as checked
+// against the AppConfigSchema.
 func ParseAppConfig(data []byte) (AppConfig, error) {
+	if err := AppConfigSchema().Validate(data); err != nil {
+		return AppConfig{}, err
+	}
+
 	var cfg AppConfig
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return AppConfig{}, err
@@ -29,22 +38,95 @@ func ParseAppConfig(data []byte) (AppConfig, error) {
 	return cfg, nil
 }
 
-func sanitizeJSONC(data []byte) ([]byte, error) {
-	appConfigJSONCReader := bytes.NewReader(data)
+// upgradeAppConfigFile runs the pending config migrations on the app config
+// at path and adds the embedded defaults it misses. Unless the environment
+// variable APP_CONFIG_READ_ONLY is true, the upgraded file is written back,
+// keeping a copy of the previous one, see backUpAppConfigFile. It returns the
+// upgraded contents.
+func upgradeAppConfigFile(path string, data []byte) ([]byte, error) {
+	migrated, applied, err := MigrateAppConfigJSONC(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate '%s': %w", path, err)
+	}
+
+	merged, added, err := MergeAppConfigJSONC(migrated, config.AppConfigJSONC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge defaults into '%s': %w", path, err)
+	}
+	if bytes.Equal(merged, data) {
+		return data, nil
+	}
+
+	readOnly := appConfigReadOnly()
+	backupPath := ""
+	if !readOnly {
+		// only written once the upgrade succeeded, the user's file is never
+		// touched without a backup
+		backupPath, err = backUpAppConfigFile(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up '%s': %w", path, err)
+		}
+		if err := os.WriteFile(path, merged, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write upgraded '%s': %w", path, err)
+		}
+	}
+
+	SetDebugSection("Config Upgrade", func(w io.Writer) {
+		if readOnly {
+			fmt.Fprintf(w, "Upgraded %s in memory only (APP_CONFIG_READ_ONLY)\n", path)
+		} else {
+			fmt.Fprintf(w, "Upgraded %s (backup: %s)\n", path, backupPath)
+		}
+		for _, migration := range applied {
+			fmt.Fprintf(w, "  migrated %s\n", migration)
+		}
+		for _, key := range added {
+			fmt.Fprintf(w, "  added %s\n", key)
+		}
+	})
+
+	return merged, nil
+}
+
+// appConfigReadOnly reports whether the environment variable
+// APP_CONFIG_READ_ONLY forbids writing to pb_data, e.g. on a read-only mount.
+func appConfigReadOnly() bool {
+	readOnly, _ := strconv.ParseBool(os.Getenv("APP_CONFIG_READ_ONLY"))
+	return readOnly
+}
 
-	jsoncFilter, err := jsonc.New(appConfigJSONCReader, true, "")
+// backUpAppConfigFile writes data, the app config at path before an upgrade,
+// to '<path>.v<configVersion>.bak' and returns the name of the backup. An
+// existing backup of the same version is kept, as it is the closest to the
+// file the user wrote.
+func backUpAppConfigFile(path string, data []byte) (string, error) {
+	tree, err := NewConfigTree(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create JSONC filter: %w", err)
+		return "", err
+	}
+	version := initialConfigVersion
+	if _, err := tree.Get("configVersion", &version); err != nil {
+		return "", err
 	}
 
-	sanitizedJSON, err := io.ReadAll(jsoncFilter)
+	backupPath := fmt.Sprintf("%s.v%d.bak", path, version)
+	file, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return backupPath, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read JSONC filter: %w", err)
+		return "", err
 	}
 
-	return sanitizedJSON, nil
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return "", err
+	}
+	return backupPath, file.Close()
 }
 
+// Get returns the app config, loading it on the first call. After a Reload it
+// returns the reloaded config.
 func Get() (AppConfig, error) {
 	appConfigMutex.Lock()
 	defer appConfigMutex.Unlock()
@@ -52,6 +134,23 @@ func Get() (AppConfig, error) {
 		return loadedAppConfig, nil
 	}
 
+	cfg, err := load()
+	if err != nil {
+		return AppConfig{}, err
+	}
+
+	loadedAppConfig = cfg
+	appConfigLoaded = true
+
+	return loadedAppConfig, nil
+}
+
+// load reads the app config from pb_data, upgrading or creating its file
+// unless APP_CONFIG_READ_ONLY is true, applies the environment and validates
+// the result. The variables of the
+// .env file are only kept if that succeeds, so a rejected Reload leaves the
+// environment as it was.
+func load() (AppConfig, error) {
 	sanitizedAppConfigJSON, err := sanitizeJSONC(config.AppConfigJSONC)
 	if err != nil {
 		return AppConfig{}, fmt.Errorf("failed to sanitize embedded 'app.config.jsonc': %w", err)
@@ -62,14 +161,56 @@ func Get() (AppConfig, error) {
 		return AppConfig{}, fmt.Errorf("failed to parse embedded 'app.config.jsonc': %w", err)
 	}
 
-	pb_data, err := filepath.Abs("./pb_data")
+	pb_data, err := dataDir()
 	if err != nil {
-		return AppConfig{}, fmt.Errorf("failed to determine absolute path for './pb_data': %w", err)
+		return AppConfig{}, err
 	}
 
-	err = os.MkdirAll(pb_data, 0755)
-	if err != nil {
-		return AppConfig{}, fmt.Errorf("failed to create directory './pb_data': %w", err)
+	// set once the config is valid, see applyDotEnv
+	valid := false
+
+	// loaded first, it may set APP_CONFIG_READ_ONLY
+	dotEnv := filepath.Join(pb_data, ".env")
+	_, err = os.Stat(dotEnv)
+	if err != nil && !os.IsNotExist(err) {
+		return AppConfig{}, fmt.Errorf("failed to stat '%s': %w", dotEnv, err)
+	}
+	dotEnvExists := err == nil
+
+	if dotEnvExists {
+		restoreEnv, err := applyDotEnv(dotEnv)
+		if err != nil {
+			return AppConfig{}, fmt.Errorf("failed to load existing .env from '%s': %w", dotEnv, err)
+		}
+		defer func() {
+			if !valid {
+				restoreEnv()
+			}
+		}()
+	}
+
+	// nothing is written to pb_data in read-only mode, the embedded defaults
+	// are used if the config file is missing
+	readOnly := appConfigReadOnly()
+	if !readOnly {
+		err = os.MkdirAll(pb_data, 0755)
+		if err != nil {
+			return AppConfig{}, fmt.Errorf("failed to create directory './pb_data': %w", err)
+		}
+
+		// written next to the config, so editors offer completion and validation
+		schemaPath := filepath.Join(pb_data, "app.config.schema.json")
+		if _, err := WriteJSONSchema(schemaPath); err != nil {
+			return AppConfig{}, err
+		}
+
+		if !dotEnvExists {
+			f, err := os.Create(dotEnv)
+			if err != nil {
+				return AppConfig{}, fmt.Errorf("failed to create new .env at '%s': %w", dotEnv, err)
+			}
+			f.Close()
+		}
 	}
 
 	appConfigPath := filepath.Join(pb_data, "app.config.jsonc")
@@ -86,6 +227,11 @@ func Get() (AppConfig, error) {
 			return AppConfig{}, fmt.Errorf("failed to read existing 'app.config.jsonc' from '%s': %w", appConfigPath, err)
 		}
 
+		data, err = upgradeAppConfigFile(appConfigPath, data)
+		if err != nil {
+			return AppConfig{}, err
+		}
+
 		sanitizedData, err := sanitizeJSONC(data)
 		if err != nil {
 			return AppConfig{}, fmt.Errorf("failed to sanitize existing 'app.config.jsonc' from '%s': %w", appConfigPath, err)
@@ -98,43 +244,115 @@ func Get() (AppConfig, error) {
 
 		jsonReader = bytes.NewReader(sanitizedData)
 	} else {
-		err = os.WriteFile(appConfigPath, config.AppConfigJSONC, 0644)
-		if err != nil {
-			return AppConfig{}, fmt.Errorf("failed to write default 'app.config.jsonc' to '%s': %w", appConfigPath, err)
+		if !readOnly {
+			err = os.WriteFile(appConfigPath, config.AppConfigJSONC, 0644)
+			if err != nil {
+				return AppConfig{}, fmt.Errorf("failed to write default 'app.config.jsonc' to '%s': %w", appConfigPath, err)
+			}
 		}
 		jsonReader = bytes.NewReader(sanitizedAppConfigJSON)
 	}
 
-	dotEnv := filepath.Join(pb_data, ".env")
-	_, err = os.Stat(dotEnv)
-	if err != nil && !os.IsNotExist(err) {
-		return AppConfig{}, fmt.Errorf("failed to stat '%s': %w", dotEnv, err)
+	var cfg AppConfig
+	err = cleanenv.ParseJSON(jsonReader, &cfg)
+	if err != nil {
+		return AppConfig{}, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
-	if err == nil {
-		err = godotenv.Load(dotEnv)
-		if err != nil {
-			return AppConfig{}, fmt.Errorf("failed to load existing .env from '%s': %w", dotEnv, err)
-		}
-	} else {
-		f, err := os.Create(dotEnv)
-		if err != nil {
-			return AppConfig{}, fmt.Errorf("failed to create new .env at '%s': %w", dotEnv, err)
-		}
-		f.Close()
+	err = cleanenv.ReadEnv(&cfg)
+	if err != nil {
+		return AppConfig{}, fmt.Errorf("failed to read environment variables: %w", err)
 	}
 
-	err = cleanenv.ParseJSON(jsonReader, &loadedAppConfig)
+	// warnings are left to the caller, errors stop the startup
+	err = cfg.Validate().Err()
 	if err != nil {
-		return AppConfig{}, fmt.Errorf("failed to parse JSON: %w", err)
+		return AppConfig{}, fmt.Errorf("invalid app config:\n%w", err)
 	}
 
-	err = cleanenv.ReadEnv(&loadedAppConfig)
+	valid = true
+	return cfg, nil
+}
+
+func dataDir() (string, error) {
+	pb_data, err := filepath.Abs("./pb_data")
 	if err != nil {
-		return AppConfig{}, fmt.Errorf("failed to read environment variables: %w", err)
+		return "", fmt.Errorf("failed to determine absolute path for './pb_data': %w", err)
 	}
+	return pb_data, nil
+}
 
-	appConfigLoaded = true
+// Files returns the paths of the files the app config is loaded from,
+// 'app.config.jsonc' and '.env' in pb_data.
+func Files() ([]string, error) {
+	pb_data, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		filepath.Join(pb_data, "app.config.jsonc"),
+		filepath.Join(pb_data, ".env"),
+	}, nil
+}
 
-	return loadedAppConfig, nil
+// dotEnvApplied holds the variables set from the .env file, so a reload can
+// update and remove them. Variables of the process environment are never
+// overridden.
+var dotEnvApplied = make(map[string]bool)
+
+// applyDotEnv sets the variables of the .env file at path and unsets the ones
+// removed from it since the last call. It returns a function undoing that.
+func applyDotEnv(path string) (func(), error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	type previousValue struct {
+		value   string
+		set     bool
+		applied bool
+	}
+	previous := make(map[string]previousValue)
+	remember := func(key string) {
+		if _, ok := previous[key]; !ok {
+			value, set := os.LookupEnv(key)
+			previous[key] = previousValue{value: value, set: set, applied: dotEnvApplied[key]}
+		}
+	}
+	restore := func() {
+		for key, value := range previous {
+			if value.set {
+				os.Setenv(key, value.value)
+			} else {
+				os.Unsetenv(key)
+			}
+			if value.applied {
+				dotEnvApplied[key] = true
+			} else {
+				delete(dotEnvApplied, key)
+			}
+		}
+	}
+
+	for key := range dotEnvApplied {
+		if _, ok := values[key]; !ok {
+			remember(key)
+			os.Unsetenv(key)
+			delete(dotEnvApplied, key)
+		}
+	}
+
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set && !dotEnvApplied[key] {
+			continue
+		}
+		remember(key)
+		if err := os.Setenv(key, value); err != nil {
+			restore()
+			return nil, fmt.Errorf("failed to set '%s': %w", key, err)
+		}
+		dotEnvApplied[key] = true
+	}
+	return restore, nil
 }
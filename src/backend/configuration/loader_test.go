@@ -1,6 +1,9 @@
 package configuration
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/yerTools/simple-frontend-stack/config"
@@ -17,3 +20,106 @@ func TestAppConfigJSONC(t *testing.T) {
 		t.Fatalf("failed to parse embedded 'app.config.jsonc': %v", err)
 	}
 }
+
+func TestUpgradeAppConfigFile(t *testing.T) {
+	t.Setenv("APP_CONFIG_READ_ONLY", "")
+
+	path := filepath.Join(t.TempDir(), "app.config.jsonc")
+	original := []byte("{\n  \"general\": {\n    \"name\": \"Mine\",\n  },\n}\n")
+
+	upgraded, err := upgradeAppConfigFile(path, original)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	backupPath := path + ".v1.bak"
+	backup, err := os.ReadFile(backupPath)
+	if err != nil || string(backup) != string(original) {
+		t.Fatalf("expected the original as backup, got %v:\n%s", err, backup)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil || string(written) != string(upgraded) {
+		t.Fatalf("expected the upgraded config to be written, got %v", err)
+	}
+
+	sanitized, err := sanitizeJSONC(upgraded)
+	if err != nil {
+		t.Fatalf("failed to sanitize upgraded config: %v", err)
+	}
+	cfg, err := ParseAppConfig(sanitized)
+	if err != nil {
+		t.Fatalf("failed to parse upgraded config: %v\n%s", err, upgraded)
+	}
+	if cfg.General.Name != "Mine" {
+		t.Errorf("expected the existing name to be kept, got %q", cfg.General.Name)
+	}
+	if cfg.ConfigVersion != LatestConfigVersion() {
+		t.Errorf("expected config version %d, got %d", LatestConfigVersion(), cfg.ConfigVersion)
+	}
+	if !strings.HasPrefix(string(upgraded), "{\n  // The version of this file's layout.") {
+		t.Errorf("expected the config version to be the first key:\n%s", upgraded)
+	}
+
+	again, err := upgradeAppConfigFile(path, upgraded)
+	if err != nil || string(again) != string(upgraded) {
+		t.Fatalf("expected upgrading again to change nothing, got %v", err)
+	}
+
+	// a restart after the upgrade reads the recorded version
+	versioned := strings.Replace(string(upgraded), `"name": "Mine"`, `"name": "Restarted"`, 1)
+	if _, err := upgradeAppConfigFile(path, []byte(versioned)); err != nil {
+		t.Fatalf("unexpected error upgrading a versioned config: %v", err)
+	}
+
+	// a later upgrade of the same version keeps the first backup
+	if _, err := upgradeAppConfigFile(path, []byte("{}")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	backup, err = os.ReadFile(backupPath)
+	if err != nil || string(backup) != string(original) {
+		t.Fatalf("expected the first backup to be kept, got %v:\n%s", err, backup)
+	}
+}
+
+func TestUpgradeAppConfigFileReadOnly(t *testing.T) {
+	t.Setenv("APP_CONFIG_READ_ONLY", "true")
+
+	path := filepath.Join(t.TempDir(), "app.config.jsonc")
+	if _, err := upgradeAppConfigFile(path, []byte("{}")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written, got %v", err)
+	}
+	if _, err := os.Stat(path + ".v1.bak"); !os.IsNotExist(err) {
+		t.Errorf("expected no backup to be written, got %v", err)
+	}
+}
+
+func TestLoadReadOnlyWritesNothing(t *testing.T) {
+	pbData := useTempDataDir(t)
+	if err := os.Mkdir(pbData, 0o755); err != nil {
+		t.Fatalf("failed to create pb_data: %v", err)
+	}
+	// set by the .env file, which is read before anything is written
+	if err := os.WriteFile(filepath.Join(pbData, ".env"), []byte("APP_CONFIG_READ_ONLY=true\n"), 0o644); err != nil {
+		t.Fatalf("failed to write .env: %v", err)
+	}
+
+	cfg, err := Get()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ConfigVersion != LatestConfigVersion() {
+		t.Errorf("expected the embedded defaults, got config version %d", cfg.ConfigVersion)
+	}
+	if _, err := Reload(); err != nil {
+		t.Fatalf("unexpected error reloading: %v", err)
+	}
+
+	entries, err := os.ReadDir(pbData)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected only the .env file in pb_data, got %v and error %v", entries, err)
+	}
+}
@@ -0,0 +1,452 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// jsoncNode is a value of a JSONC document with its position, so documents
+// can be edited without losing their comments and formatting.
+type jsoncNode struct {
+	start int
+	end   int
+
+	// members and close, the position of the closing brace, are only set for
+	// objects.
+	object  bool
+	members []*jsoncMember
+	close   int
+}
+
+type jsoncMember struct {
+	key      string
+	keyStart int
+	keyEnd   int
+	value    *jsoncNode
+
+	// prevEnd is the end of the token before the member, its leading
+	// comments start after it. end is after the value and its comma.
+	prevEnd int
+	end     int
+	comma   bool
+}
+
+func (n *jsoncNode) member(key string) *jsoncMember {
+	for _, member := range n.members {
+		if member.key == key {
+			return member
+		}
+	}
+	return nil
+}
+
+type jsoncParser struct {
+	data []byte
+	pos  int
+}
+
+// parseJSONC parses a JSON document with comments and trailing commas.
+func parseJSONC(data []byte) (*jsoncNode, error) {
+	p := &jsoncParser{data: data}
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+
+	node, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+	if p.pos != len(data) {
+		return nil, p.errorf("unexpected content after the document")
+	}
+	return node, nil
+}
+
+// sanitizeJSONC returns the JSON of a JSONC document, without comments,
+// trailing commas and whitespace.
+func sanitizeJSONC(data []byte) ([]byte, error) {
+	if _, err := parseJSONC(data); err != nil {
+		return nil, fmt.Errorf("invalid JSONC: %w", err)
+	}
+
+	p := &jsoncParser{data: data}
+	sanitized := make([]byte, 0, len(data))
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(data) {
+			return sanitized, nil
+		}
+
+		switch data[p.pos] {
+		case '"':
+			start := p.pos
+			if _, err := p.string(); err != nil {
+				return nil, err
+			}
+			sanitized = append(sanitized, data[start:p.pos]...)
+		case ',':
+			p.pos++
+			if err := p.skip(); err != nil {
+				return nil, err
+			}
+			if p.pos < len(data) && (data[p.pos] == '}' || data[p.pos] == ']') {
+				continue
+			}
+			sanitized = append(sanitized, ',')
+		default:
+			sanitized = append(sanitized, data[p.pos])
+			p.pos++
+		}
+	}
+}
+
+func (p *jsoncParser) errorf(format string, args ...any) error {
+	line := bytes.Count(p.data[:p.pos], []byte("\n")) + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skip moves past whitespace and comments.
+func (p *jsoncParser) skip() error {
+	for p.pos < len(p.data) {
+		switch {
+		case strings.IndexByte(" \t\r\n", p.data[p.pos]) != -1:
+			p.pos++
+		case bytes.HasPrefix(p.data[p.pos:], []byte("//")):
+			end := bytes.IndexByte(p.data[p.pos:], '\n')
+			if end == -1 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end
+			}
+		case bytes.HasPrefix(p.data[p.pos:], []byte("/*")):
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end == -1 {
+				return p.errorf("unterminated comment")
+			}
+			p.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *jsoncParser) value() (*jsoncNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of the document")
+	}
+
+	switch p.data[p.pos] {
+	case '{':
+		return p.object()
+	case '[':
+		return p.array()
+	case '"':
+		start := p.pos
+		if _, err := p.string(); err != nil {
+			return nil, err
+		}
+		return &jsoncNode{start: start, end: p.pos}, nil
+	default:
+		start := p.pos
+		for p.pos < len(p.data) && strings.IndexByte(",:{}[]/ \t\r\n", p.data[p.pos]) == -1 {
+			p.pos++
+		}
+		if p.pos == start {
+			return nil, p.errorf("unexpected '%c'", p.data[p.pos])
+		}
+		return &jsoncNode{start: start, end: p.pos}, nil
+	}
+}
+
+func (p *jsoncParser) string() (string, error) {
+	start := p.pos
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		switch p.data[p.pos] {
+		case '\\':
+			p.pos++
+		case '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal(p.data[start:p.pos], &s); err != nil {
+				return "", p.errorf("invalid string: %v", err)
+			}
+			return s, nil
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *jsoncParser) object() (*jsoncNode, error) {
+	node := &jsoncNode{start: p.pos, object: true}
+	p.pos++
+	prevEnd := p.pos
+
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unterminated object")
+		}
+		if p.data[p.pos] == '}' {
+			node.close = p.pos
+			p.pos++
+			node.end = p.pos
+			return node, nil
+		}
+		if p.data[p.pos] != '"' {
+			return nil, p.errorf("expected a key")
+		}
+
+		member := &jsoncMember{keyStart: p.pos, prevEnd: prevEnd}
+		key, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		member.key = key
+		member.keyEnd = p.pos
+
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after key '%s'", key)
+		}
+		p.pos++
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+
+		member.value, err = p.value()
+		if err != nil {
+			return nil, err
+		}
+		member.end = p.pos
+
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+			member.end = p.pos
+			member.comma = true
+		}
+
+		node.members = append(node.members, member)
+		prevEnd = member.end
+	}
+}
+
+func (p *jsoncParser) array() (*jsoncNode, error) {
+	node := &jsoncNode{start: p.pos}
+	p.pos++
+
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.data[p.pos] == ']' {
+			p.pos++
+			node.end = p.pos
+			return node, nil
+		}
+
+		if _, err := p.value(); err != nil {
+			return nil, err
+		}
+
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
+
+// jsoncEdit replaces the bytes from start to end with text.
+type jsoncEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// applyEdits applies non-overlapping edits to data, edits at the same
+// position are inserted in their order.
+func applyEdits(data []byte, edits []jsoncEdit) []byte {
+	edits = slices.Clone(edits)
+	slices.SortStableFunc(edits, func(a jsoncEdit, b jsoncEdit) int {
+		return a.start - b.start
+	})
+
+	var out bytes.Buffer
+	last := 0
+	for _, edit := range edits {
+		out.Write(data[last:edit.start])
+		out.WriteString(edit.text)
+		last = edit.end
+	}
+	out.Write(data[last:])
+	return out.Bytes()
+}
+
+// insertEdits returns the edits inserting the member text, already indented
+// by memberIndent, into object after anchor or, if anchor is nil, as its first
+// member. A comma is added after anchor if addComma is set and it has none.
+func insertEdits(data []byte, object *jsoncNode, anchor *jsoncMember, text string, addComma bool) []jsoncEdit {
+	var edits []jsoncEdit
+
+	var pos int
+	if anchor != nil {
+		if addComma && !anchor.comma {
+			edits = append(edits, jsoncEdit{start: anchor.value.end, end: anchor.value.end, text: ","})
+		}
+		pos = restOfLine(data, anchor.end)
+	} else {
+		pos = restOfLine(data, object.start+1)
+	}
+
+	text = "\n" + text + ","
+	if len(object.members) == 0 && pos == object.close {
+		text += "\n" + lineIndent(data, object.start)
+	}
+	return append(edits, jsoncEdit{start: pos, end: pos, text: text})
+}
+
+// deleteEdit returns the edit removing member with its leading comments and
+// the rest of its line.
+func deleteEdit(data []byte, member *jsoncMember) jsoncEdit {
+	start := member.keyStart
+	if newline := bytes.IndexByte(data[member.prevEnd:member.keyStart], '\n'); newline != -1 {
+		// from the line break before the leading comments
+		start = member.prevEnd + newline
+	}
+	return jsoncEdit{start: start, end: restOfLine(data, member.end)}
+}
+
+// restOfLine returns the end of the line at pos if only whitespace and a line
+// comment follow on it, pos otherwise.
+func restOfLine(data []byte, pos int) int {
+	end := bytes.IndexByte(data[pos:], '\n')
+	if end == -1 {
+		end = len(data) - pos
+	}
+
+	rest := bytes.TrimSpace(data[pos : pos+end])
+	if len(rest) == 0 || bytes.HasPrefix(rest, []byte("//")) {
+		return pos + len(bytes.TrimRight(data[pos:pos+end], " \t\r"))
+	}
+	return pos
+}
+
+// memberText returns member with its leading comments as key, indented by
+// indent instead of its original indentation.
+func memberText(data []byte, member *jsoncMember, key string, indent string) string {
+	start := member.keyStart
+	if newline := bytes.IndexByte(data[member.prevEnd:member.keyStart], '\n'); newline != -1 {
+		start = member.prevEnd + newline + 1
+	}
+
+	quotedKey, _ := json.Marshal(key)
+	text := string(data[start:member.keyStart]) + string(quotedKey) + string(data[member.keyEnd:member.value.end])
+
+	original := lineIndent(data, member.keyStart)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i == 0 && start == member.keyStart {
+			lines[i] = indent + line
+			continue
+		}
+		lines[i] = indent + strings.TrimPrefix(line, original)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// memberIndent returns the indentation of the members of object.
+func memberIndent(data []byte, object *jsoncNode) string {
+	if len(object.members) > 0 {
+		return lineIndent(data, object.members[0].keyStart)
+	}
+	return lineIndent(data, object.start) + "  "
+}
+
+// lineIndent returns the whitespace the line of pos starts with.
+func lineIndent(data []byte, pos int) string {
+	lineStart := bytes.LastIndexByte(data[:pos], '\n') + 1
+	line := data[lineStart:pos]
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
+
+type jsoncMerge struct {
+	existing []byte
+	defaults []byte
+	edits    []jsoncEdit
+	added    []string
+}
+
+// MergeAppConfigJSONC adds the keys of defaults missing in existing, together
+// with their doc comments, and returns the merged document and the paths of
+// the added keys. Existing values, comments and formatting are left alone.
+func MergeAppConfigJSONC(existing []byte, defaults []byte) ([]byte, []string, error) {
+	existingRoot, err := parseJSONC(existing)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse existing config: %w", err)
+	}
+	defaultsRoot, err := parseJSONC(defaults)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse default config: %w", err)
+	}
+	if !existingRoot.object || !defaultsRoot.object {
+		return nil, nil, fmt.Errorf("expected the configs to be objects")
+	}
+
+	m := &jsoncMerge{existing: existing, defaults: defaults}
+	m.mergeObject(nil, existingRoot, defaultsRoot)
+
+	if len(m.edits) == 0 {
+		return existing, nil, nil
+	}
+	return applyEdits(existing, m.edits), m.added, nil
+}
+
+func (m *jsoncMerge) mergeObject(path []string, existing *jsoncNode, defaults *jsoncNode) {
+	commaAdded := make(map[*jsoncMember]bool)
+
+	for i, member := range defaults.members {
+		memberPath := append(slices.Clone(path), member.key)
+
+		if current := existing.member(member.key); current != nil {
+			if current.value.object && member.value.object {
+				m.mergeObject(memberPath, current.value, member.value)
+			}
+			continue
+		}
+
+		// insert after the closest preceding key the existing object has
+		var anchor *jsoncMember
+		for j := i - 1; j >= 0 && anchor == nil; j-- {
+			anchor = existing.member(defaults.members[j].key)
+		}
+
+		text := memberText(m.defaults, member, member.key, memberIndent(m.existing, existing))
+		m.edits = append(m.edits, insertEdits(m.existing, existing, anchor, text, !commaAdded[anchor])...)
+		if anchor != nil {
+			commaAdded[anchor] = true
+		}
+
+		m.added = append(m.added, strings.Join(memberPath, "."))
+	}
+}
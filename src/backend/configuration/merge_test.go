@@ -0,0 +1,127 @@
+package configuration
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/yerTools/simple-frontend-stack/config"
+)
+
+func TestMergeAppConfigJSONC(t *testing.T) {
+	defaults := `{
+  "general": {
+    // The name.
+    "name": "Default",
+    // The URL.
+    "url": "https://example.com/",
+    // Debug mode.
+    "debug": false,
+  },
+  // The server.
+  "server": {
+    "http": {
+      // The port.
+      "port": 8161,
+    },
+  },
+}
+`
+	existing := `{
+  "general": {
+    // My name.
+    "name": "Mine" // keep this comment
+  },
+}
+`
+	expected := `{
+  "general": {
+    // My name.
+    "name": "Mine", // keep this comment
+    // The URL.
+    "url": "https://example.com/",
+    // Debug mode.
+    "debug": false,
+  },
+  // The server.
+  "server": {
+    "http": {
+      // The port.
+      "port": 8161,
+    },
+  },
+}
+`
+
+	merged, added, err := MergeAppConfigJSONC([]byte(existing), []byte(defaults))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(merged) != expected {
+		t.Fatalf("unexpected merge result:\n%s", merged)
+	}
+	if !reflect.DeepEqual(added, []string{"general.url", "general.debug", "server"}) {
+		t.Fatalf("unexpected added keys %v", added)
+	}
+
+	again, added, err := MergeAppConfigJSONC(merged, []byte(defaults))
+	if err != nil || string(again) != expected || len(added) != 0 {
+		t.Fatalf("expected merging again to change nothing, got %v, %v:\n%s", added, err, again)
+	}
+}
+
+func TestMergeAppConfigJSONCIntoEmptyObjects(t *testing.T) {
+	defaults := `{"server": {"http": {"port": 8161}}}`
+
+	merged, _, err := MergeAppConfigJSONC([]byte(`{"server": {}}`), []byte(defaults))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sanitized, err := sanitizeJSONC(merged)
+	if err != nil {
+		t.Fatalf("failed to sanitize merged config: %v\n%s", err, merged)
+	}
+	cfg, err := ParseAppConfig(sanitized)
+	if err != nil || cfg.Server.HTTP.Port != 8161 {
+		t.Fatalf("expected the port to be merged, got %d, %v:\n%s", cfg.Server.HTTP.Port, err, merged)
+	}
+}
+
+func TestMergeAppConfigJSONCLeavesCompleteConfigAlone(t *testing.T) {
+	merged, added, err := MergeAppConfigJSONC(config.AppConfigJSONC, config.AppConfigJSONC)
+	if err != nil || string(merged) != string(config.AppConfigJSONC) || len(added) != 0 {
+		t.Fatalf("expected the embedded config to be left alone, got %v, %v", added, err)
+	}
+}
+
+func TestSanitizeJSONC(t *testing.T) {
+	tests := []struct {
+		jsonc    string
+		expected string
+	}{
+		{jsonc: "{\n  // comment\n  \"a\": 1, /* inline */ \"b\": [true, null,],\n}\n", expected: `{"a":1,"b":[true,null]}`},
+		{jsonc: `{"url": "https://example.com/*/", "c": ", }"}`, expected: `{"url":"https://example.com/*/","c":", }"}`},
+		{jsonc: "1 // a single value", expected: "1"},
+	}
+
+	for _, test := range tests {
+		sanitized, err := sanitizeJSONC([]byte(test.jsonc))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.jsonc, err)
+		}
+		if string(sanitized) != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.jsonc, test.expected, sanitized)
+		}
+	}
+
+	if _, err := sanitizeJSONC([]byte(`{"a": 1`)); err == nil {
+		t.Error("expected an error for an unterminated object")
+	}
+
+	// the embedded config has to survive with its closing brace
+	sanitized, err := sanitizeJSONC(config.AppConfigJSONC)
+	if err != nil || !json.Valid(sanitized) {
+		t.Fatalf("expected valid JSON from the embedded config, got %v:\n%s", err, sanitized)
+	}
+}
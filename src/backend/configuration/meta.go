@@ -14,10 +14,21 @@ type MetaNode struct {
 	Value    any
 	Children []MetaNode
 
+	// Type is the declared type of the struct field, nil for the root.
+	Type reflect.Type
+
 	Env          string
 	Description  string
 	EnvDefault   string
 	EnvSeparator string
+
+	// Public marks values that may be sent to the frontend, set with the
+	// `public:"true"` struct tag. Children of a public node are public too.
+	Public bool
+
+	// Live marks values the running app applies when the config is reloaded,
+	// set with the `reload:"live"` struct tag. Other changes need a restart.
+	Live bool
 }
 
 func (n MetaNode) Walk(f func(node MetaNode) error) error {
@@ -32,6 +43,20 @@ func (n MetaNode) Walk(f func(node MetaNode) error) error {
 	return nil
 }
 
+func (n *MetaNode) markPublic() {
+	n.Public = true
+	for i := range n.Children {
+		n.Children[i].markPublic()
+	}
+}
+
+func (n *MetaNode) markLive() {
+	n.Live = true
+	for i := range n.Children {
+		n.Children[i].markLive()
+	}
+}
+
 var (
 	loadedMetaNode MetaNode
 	metaNodeLoaded bool
@@ -95,10 +120,17 @@ func createMetaNode(parent []string, name string, value any) MetaNode {
 		}
 
 		childNode := createMetaNode(absolutePath, fieldName, val.Field(i).Interface())
+		childNode.Type = fieldType.Type
 		childNode.Env = fieldType.Tag.Get("env")
 		childNode.Description = fieldType.Tag.Get("env-description")
 		childNode.EnvDefault = fieldType.Tag.Get("env-default")
 		childNode.EnvSeparator = fieldType.Tag.Get("env-separator")
+		if fieldType.Tag.Get("public") == "true" {
+			childNode.markPublic()
+		}
+		if fieldType.Tag.Get("reload") == "live" {
+			childNode.markLive()
+		}
 
 		node.Children = append(node.Children, childNode)
 	}
@@ -153,24 +185,34 @@ func EnvironmentMap() (map[string]MetaNode, error) {
 	return envMap, nil
 }
 
+// HTMLMap returns the `%APP_CONFIG_*%` variables of the public config values,
+// see MetaNode.Public. Other values, e.g. the ports or the encryption key, are
+// not available to HTML files.
 func HTMLMap() (map[string]string, error) {
-	environmentMap, err := EnvironmentMap()
+	meta, err := Meta()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load environment map for HTML map: %w", err)
+		return nil, fmt.Errorf("failed to load meta for HTML map: %w", err)
 	}
 
+	return htmlMap(meta), nil
+}
+
+func htmlMap(root MetaNode) map[string]string {
 	var sb strings.Builder
 
 	htmlMap := make(map[string]string)
-	for envVar, node := range environmentMap {
+	_ = root.Walk(func(node MetaNode) error {
+		if node.Env == "" || !node.Public {
+			return nil
+		}
 
 		sb.WriteByte('%')
 
-		if strings.HasPrefix(envVar, "APP_") {
+		if strings.HasPrefix(node.Env, "APP_") {
 			sb.WriteString("APP_CONFIG_")
-			sb.WriteString(envVar[4:])
+			sb.WriteString(node.Env[4:])
 		} else {
-			sb.WriteString(envVar)
+			sb.WriteString(node.Env)
 		}
 
 		sb.WriteByte('%')
@@ -182,7 +224,8 @@ func HTMLMap() (map[string]string, error) {
 		htmlMap[sb.String()] = valueStr
 
 		sb.Reset()
-	}
+		return nil
+	})
 
-	return htmlMap, nil
+	return htmlMap
 }
@@ -0,0 +1,340 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ConfigMigration upgrades the app.config.jsonc of the previous version to
+// the version it has been registered with.
+type ConfigMigration func(tree *ConfigTree) error
+
+type registeredConfigMigration struct {
+	version     int
+	description string
+	up          ConfigMigration
+}
+
+// initialConfigVersion is the version of the app.config.jsonc layout before
+// the first migration.
+const initialConfigVersion = 1
+
+var (
+	configMigrations      []registeredConfigMigration
+	configMigrationsMutex sync.Mutex
+)
+
+// RegisterConfigMigration registers the migration upgrading app.config.jsonc
+// files to version, which is stored as their `configVersion`. Version 1 is the
+// layout before any migration, so versions start at 2. They must be unique,
+// the embedded app.config.jsonc has the latest one.
+//
+// Migrations are registered from init functions, one file per migration:
+//
+//	func init() {
+//		RegisterConfigMigration(2, "move forceDevMode into dev", func(tree *ConfigTree) error {
+//			return tree.Move("server.forceDevMode", "server.dev.force")
+//		})
+//	}
+func RegisterConfigMigration(version int, description string, up ConfigMigration) {
+	configMigrationsMutex.Lock()
+	defer configMigrationsMutex.Unlock()
+
+	if version <= initialConfigVersion {
+		panic(fmt.Sprintf("config migration version %d must be at least %d", version, initialConfigVersion+1))
+	}
+	for _, migration := range configMigrations {
+		if migration.version == version {
+			panic(fmt.Sprintf("config migration version %d is already registered", version))
+		}
+	}
+
+	configMigrations = append(configMigrations, registeredConfigMigration{
+		version:     version,
+		description: description,
+		up:          up,
+	})
+	slices.SortFunc(configMigrations, func(a registeredConfigMigration, b registeredConfigMigration) int {
+		return a.version - b.version
+	})
+}
+
+// LatestConfigVersion returns the version of the last registered migration,
+// the initial version 1 if there is none.
+func LatestConfigVersion() int {
+	configMigrationsMutex.Lock()
+	defer configMigrationsMutex.Unlock()
+
+	if len(configMigrations) == 0 {
+		return initialConfigVersion
+	}
+	return configMigrations[len(configMigrations)-1].version
+}
+
+// MigrateAppConfigJSONC runs the migrations newer than the `configVersion` of
+// data and returns the upgraded document with the descriptions of the applied
+// migrations. Files without a version predate it and have the initial layout.
+func MigrateAppConfigJSONC(data []byte) ([]byte, []string, error) {
+	tree, err := NewConfigTree(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	version := initialConfigVersion
+	if _, err := tree.Get("configVersion", &version); err != nil {
+		return nil, nil, err
+	}
+
+	configMigrationsMutex.Lock()
+	migrations := slices.Clone(configMigrations)
+	configMigrationsMutex.Unlock()
+
+	if latest := LatestConfigVersion(); version > latest {
+		return nil, nil, fmt.Errorf("config version %d is newer than the latest supported version %d", version, latest)
+	}
+
+	var applied []string
+	for _, migration := range migrations {
+		if migration.version <= version {
+			continue
+		}
+
+		if err := migration.up(tree); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate config to version %d (%s): %w", migration.version, migration.description, err)
+		}
+		if err := tree.setVersion(migration.version); err != nil {
+			return nil, nil, err
+		}
+
+		applied = append(applied, fmt.Sprintf("%d: %s", migration.version, migration.description))
+	}
+
+	return tree.Bytes(), applied, nil
+}
+
+// ConfigTree is a parsed app.config.jsonc edited by config migrations. Edits
+// keep the comments and formatting of the untouched parts, moved values keep
+// their doc comments. Paths are dot separated keys, e.g. "server.http.port".
+type ConfigTree struct {
+	data []byte
+	root *jsoncNode
+}
+
+// NewConfigTree parses a JSONC document, which must be an object.
+func NewConfigTree(data []byte) (*ConfigTree, error) {
+	t := &ConfigTree{}
+	if err := t.update(data); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *ConfigTree) update(data []byte) error {
+	root, err := parseJSONC(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	if !root.object {
+		return fmt.Errorf("expected the config to be an object")
+	}
+
+	t.data, t.root = data, root
+	return nil
+}
+
+func (t *ConfigTree) edit(edits ...jsoncEdit) error {
+	return t.update(applyEdits(t.data, edits))
+}
+
+// Bytes returns the current document.
+func (t *ConfigTree) Bytes() []byte {
+	return t.data
+}
+
+// lookup returns the member at path and the object containing it. The member
+// is nil if it does not exist, the object too if a parent is missing.
+func (t *ConfigTree) lookup(path string) (*jsoncMember, *jsoncNode, error) {
+	keys := strings.Split(path, ".")
+	object := t.root
+
+	for i, key := range keys {
+		member := object.member(key)
+		if i == len(keys)-1 || member == nil {
+			if i < len(keys)-1 {
+				object = nil
+			}
+			return member, object, nil
+		}
+		if !member.value.object {
+			return nil, nil, fmt.Errorf("%s: expected an object", strings.Join(keys[:i+1], "."))
+		}
+		object = member.value
+	}
+	return nil, nil, nil
+}
+
+// Has reports whether there is a value at path.
+func (t *ConfigTree) Has(path string) bool {
+	member, _, _ := t.lookup(path)
+	return member != nil
+}
+
+// Get decodes the value at path into value and reports whether it exists.
+// Comments and trailing commas in the value are ignored.
+func (t *ConfigTree) Get(path string, value any) (bool, error) {
+	member, _, err := t.lookup(path)
+	if err != nil || member == nil {
+		return false, err
+	}
+
+	sanitized, err := sanitizeJSONC(t.data[member.value.start:member.value.end])
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := json.Unmarshal(sanitized, value); err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	return true, nil
+}
+
+// Set replaces the value at path, or appends it to its object, which is
+// created if missing.
+func (t *ConfigTree) Set(path string, value any) error {
+	member, object, err := t.lookup(path)
+	if err != nil {
+		return err
+	}
+
+	if member != nil {
+		encoded, err := t.encode(value, lineIndent(t.data, member.keyStart))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return t.edit(jsoncEdit{start: member.value.start, end: member.value.end, text: encoded})
+	}
+
+	parent, key := splitConfigPath(path)
+	if object == nil {
+		if object, err = t.ensureObject(parent); err != nil {
+			return err
+		}
+	}
+
+	indent := memberIndent(t.data, object)
+	encoded, err := t.encode(value, indent)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	quotedKey, _ := json.Marshal(key)
+	return t.insert(object, indent+string(quotedKey)+": "+encoded)
+}
+
+// Delete removes the value at path with its doc comments, a missing value is
+// ignored.
+func (t *ConfigTree) Delete(path string) error {
+	member, _, err := t.lookup(path)
+	if err != nil || member == nil {
+		return err
+	}
+	return t.edit(deleteEdit(t.data, member))
+}
+
+// Move moves the value at from with its doc comments to the path to, creating
+// its parent objects if missing. A missing value is ignored, an existing
+// value at to is an error.
+func (t *ConfigTree) Move(from string, to string) error {
+	member, _, err := t.lookup(from)
+	if err != nil || member == nil {
+		return err
+	}
+	if t.Has(to) {
+		return fmt.Errorf("%s: cannot move %s there, the key already exists", to, from)
+	}
+
+	// the text is taken before the delete shifts the positions
+	_, key := splitConfigPath(to)
+	text := memberText(t.data, member, key, "")
+	if err := t.edit(deleteEdit(t.data, member)); err != nil {
+		return err
+	}
+
+	parent, _ := splitConfigPath(to)
+	object, err := t.ensureObject(parent)
+	if err != nil {
+		return err
+	}
+
+	indent := memberIndent(t.data, object)
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = indent + lines[i]
+	}
+	return t.insert(object, strings.Join(lines, "\n"))
+}
+
+// ensureObject returns the object at path, creating it and its parents as
+// empty objects if missing.
+func (t *ConfigTree) ensureObject(path string) (*jsoncNode, error) {
+	if path == "" {
+		return t.root, nil
+	}
+
+	member, _, err := t.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		if err := t.Set(path, struct{}{}); err != nil {
+			return nil, err
+		}
+		if member, _, err = t.lookup(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if !member.value.object {
+		return nil, fmt.Errorf("%s: expected an object", path)
+	}
+	return member.value, nil
+}
+
+// insert appends the member text, indented for object, to object.
+func (t *ConfigTree) insert(object *jsoncNode, text string) error {
+	var anchor *jsoncMember
+	if len(object.members) > 0 {
+		anchor = object.members[len(object.members)-1]
+	}
+	return t.edit(insertEdits(t.data, object, anchor, text, true)...)
+}
+
+// setVersion sets the `configVersion`, inserting it as the first key if
+// missing.
+func (t *ConfigTree) setVersion(version int) error {
+	if t.Has("configVersion") {
+		return t.Set("configVersion", version)
+	}
+
+	text := memberIndent(t.data, t.root) + `"configVersion": ` + strconv.Itoa(version)
+	return t.edit(insertEdits(t.data, t.root, nil, text, true)...)
+}
+
+// encode returns value as JSON, objects and arrays indented to continue a
+// line indented by indent.
+func (t *ConfigTree) encode(value any, indent string) (string, error) {
+	encoded, err := json.MarshalIndent(value, indent, "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func splitConfigPath(path string) (string, string) {
+	if i := strings.LastIndexByte(path, '.'); i != -1 {
+		return path[:i], path[i+1:]
+	}
+	return "", path
+}
@@ -0,0 +1,130 @@
+package configuration
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yerTools/simple-frontend-stack/config"
+)
+
+func TestConfigTree(t *testing.T) {
+	tree, err := NewConfigTree([]byte(`{
+  "server": {
+    // Force the development mode.
+    "forceDevMode": true, // set by hand
+    "indexFallback": false,
+  },
+}
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := tree.Move("server.forceDevMode", "server.dev.force"); err != nil {
+		t.Fatalf("failed to move: %v", err)
+	}
+	if err := tree.Set("server.dev.port", 5173); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+	if err := tree.Set("server.indexFallback", true); err != nil {
+		t.Fatalf("failed to replace: %v", err)
+	}
+	if err := tree.Delete("server.missing"); err != nil {
+		t.Fatalf("failed to ignore a missing key: %v", err)
+	}
+
+	expected := `{
+  "server": {
+    "indexFallback": true,
+    "dev": {
+      // Force the development mode.
+      "force": true,
+      "port": 5173,
+    },
+  },
+}
+`
+	if string(tree.Bytes()) != expected {
+		t.Fatalf("unexpected tree:\n%s", tree.Bytes())
+	}
+
+	var force bool
+	if ok, err := tree.Get("server.dev.force", &force); !ok || err != nil || !force {
+		t.Fatalf("expected the moved value, got %v, %v, %v", force, ok, err)
+	}
+
+	var dev struct {
+		Force bool `json:"force"`
+		Port  int  `json:"port"`
+	}
+	if ok, err := tree.Get("server.dev", &dev); !ok || err != nil || !dev.Force || dev.Port != 5173 {
+		t.Fatalf("expected the commented object, got %+v, %v, %v", dev, ok, err)
+	}
+
+	if err := tree.Move("server.indexFallback", "server.dev.port"); err == nil {
+		t.Error("expected an error moving onto an existing key")
+	}
+	if err := tree.Set("server.indexFallback.nested", 1); err == nil {
+		t.Error("expected an error setting a key below a value")
+	}
+}
+
+func TestMigrateAppConfigJSONC(t *testing.T) {
+	registered := slices.Clone(configMigrations)
+	t.Cleanup(func() {
+		configMigrations = registered
+	})
+
+	latest := LatestConfigVersion()
+	RegisterConfigMigration(latest+1, "rename name", func(tree *ConfigTree) error {
+		return tree.Move("general.title", "general.name")
+	})
+
+	// without a version, like the files before it was recorded
+	migrated, applied, err := MigrateAppConfigJSONC([]byte(`{
+  "general": {
+    "title": "Mine",
+  },
+}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(applied) != latest || !strings.HasSuffix(applied[len(applied)-1], ": rename name") {
+		t.Fatalf("expected all migrations to be applied, got %v", applied)
+	}
+
+	tree, err := NewConfigTree(migrated)
+	if err != nil {
+		t.Fatalf("failed to parse migrated config: %v", err)
+	}
+	var version int
+	var name string
+	if _, err := tree.Get("configVersion", &version); err != nil || version != latest+1 {
+		t.Errorf("expected config version %d, got %d, %v", latest+1, version, err)
+	}
+	if _, err := tree.Get("general.name", &name); err != nil || name != "Mine" {
+		t.Errorf("expected the renamed name, got %q, %v", name, err)
+	}
+
+	again, applied, err := MigrateAppConfigJSONC(migrated)
+	if err != nil || len(applied) != 0 || string(again) != string(migrated) {
+		t.Fatalf("expected migrating again to change nothing, got %v, %v", applied, err)
+	}
+
+	if _, _, err := MigrateAppConfigJSONC([]byte(`{"configVersion": 1000}`)); err == nil {
+		t.Error("expected an error for a config newer than the app")
+	}
+}
+
+func TestEmbeddedConfigHasLatestVersion(t *testing.T) {
+	tree, err := NewConfigTree(config.AppConfigJSONC)
+	if err != nil {
+		t.Fatalf("failed to parse embedded config: %v", err)
+	}
+
+	var version int
+	if _, err := tree.Get("configVersion", &version); err != nil || version != LatestConfigVersion() {
+		t.Fatalf("expected the embedded config at version %d, got %d, %v", LatestConfigVersion(), version, err)
+	}
+}
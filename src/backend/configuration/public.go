@@ -0,0 +1,130 @@
+package configuration
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// PublicConfig returns the public values of cfg as nested maps keyed by their
+// JSON names, ready to be sent to the frontend.
+func PublicConfig(cfg AppConfig) map[string]any {
+	root := createMetaNode([]string{}, "", cfg)
+
+	public, _ := publicValue(root).(map[string]any)
+	if public == nil {
+		return map[string]any{}
+	}
+	return public
+}
+
+// publicValue returns the value of a public leaf, or the map of the public
+// children of a section. It returns nil if nothing below node is public.
+func publicValue(node MetaNode) any {
+	if len(node.Children) == 0 {
+		if node.Public {
+			return node.Value
+		}
+		return nil
+	}
+
+	values := make(map[string]any)
+	for _, child := range node.Children {
+		if value := publicValue(child); value != nil {
+			values[child.Name] = value
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// PublicTypeScript returns a TypeScript declaration of the public config
+// values, which are available to the frontend as window.__APP_CONFIG__.
+func PublicTypeScript(cfg AppConfig) string {
+	root := createMetaNode([]string{}, "", cfg)
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated by the backend from the public fields of AppConfig. DO NOT EDIT.\n\n")
+	sb.WriteString("interface AppPublicConfig ")
+	writeTypeScriptNode(&sb, root, 0)
+	sb.WriteString("\n\ninterface Window {\n  __APP_CONFIG__: AppPublicConfig;\n}\n")
+
+	return sb.String()
+}
+
+func writeTypeScriptNode(sb *strings.Builder, node MetaNode, depth int) {
+	sb.WriteString("{\n")
+
+	indent := strings.Repeat("  ", depth+1)
+	for _, child := range node.Children {
+		if publicValue(child) == nil {
+			continue
+		}
+
+		if child.Description != "" {
+			fmt.Fprintf(sb, "%s/** %s */\n", indent, strings.ReplaceAll(child.Description, "*/", "*\\/"))
+		}
+		fmt.Fprintf(sb, "%s%s: ", indent, child.Name)
+
+		if len(child.Children) > 0 {
+			writeTypeScriptNode(sb, child, depth+1)
+		} else {
+			sb.WriteString(typeScriptType(child.Type))
+		}
+		sb.WriteString(";\n")
+	}
+
+	sb.WriteString(strings.Repeat("  ", depth))
+	sb.WriteString("}")
+}
+
+// typeScriptType maps the Go type of a config value to the TypeScript type of
+// its JSON encoding.
+func typeScriptType(typ reflect.Type) string {
+	if typ == nil {
+		return "unknown"
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return typeScriptType(typ.Elem()) + " | null"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		elem := typeScriptType(typ.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + typeScriptType(typ.Elem()) + ">"
+	default:
+		return "unknown"
+	}
+}
+
+// WritePublicTypeScript writes the PublicTypeScript declaration to path,
+// unless the file is already up to date. It reports whether it was written.
+func WritePublicTypeScript(cfg AppConfig, path string) (bool, error) {
+	declaration := PublicTypeScript(cfg)
+
+	existing, err := os.ReadFile(path)
+	if err == nil && string(existing) == declaration {
+		return false, nil
+	}
+
+	if err := os.WriteFile(path, []byte(declaration), 0o644); err != nil {
+		return false, fmt.Errorf("failed to write public config declaration '%s': %w", path, err)
+	}
+	return true, nil
+}
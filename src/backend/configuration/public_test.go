@@ -0,0 +1,66 @@
+package configuration
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPublicConfigOnlyContainsPublicFields(t *testing.T) {
+	cfg := AppConfig{
+		General: GeneralConfig{Name: "MyApp", Debug: true},
+		Server:  ServerConfig{HTTP: HTTPConfig{Port: 8161}, AllowedOrigins: []string{"*"}},
+	}
+
+	public := PublicConfig(cfg)
+
+	if len(public) != 1 {
+		t.Fatalf("expected only the general section, got %v", public)
+	}
+
+	general, ok := public["general"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected the general section to be a map, got %T", public["general"])
+	}
+	if general["name"] != "MyApp" {
+		t.Fatalf("expected the public name, got %v", general["name"])
+	}
+	if _, ok := general["debug"]; ok {
+		t.Fatalf("expected debug to not be public, got %v", general)
+	}
+}
+
+func TestHTMLMapOnlyContainsPublicFields(t *testing.T) {
+	key := "secret"
+	cfg := AppConfig{
+		General: GeneralConfig{Name: "MyApp"},
+		Server: ServerConfig{
+			BasePath:      "app",
+			HTTP:          HTTPConfig{Port: 8161},
+			EncryptionKey: &key,
+		},
+	}
+
+	vars := htmlMap(createMetaNode([]string{}, "", cfg))
+
+	if vars["%APP_CONFIG_GENERAL_NAME%"] != "MyApp" {
+		t.Fatalf("expected the public name, got %v", vars)
+	}
+	for _, name := range []string{"%APP_CONFIG_SERVER_HTTP_PORT%", "%APP_CONFIG_SERVER_ENCRYPTION_KEY%", "%APP_CONFIG_SERVER_BASE_PATH%"} {
+		if _, ok := vars[name]; ok {
+			t.Errorf("expected %s to not be available to HTML", name)
+		}
+	}
+}
+
+func TestPublicTypeScriptIsUpToDate(t *testing.T) {
+	const path = "../../frontend/types/appConfig.d.ts"
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read '%s': %v", path, err)
+	}
+
+	if string(existing) != PublicTypeScript(AppConfig{}) {
+		t.Fatalf("'%s' is outdated, start the app in dev mode to regenerate it", path)
+	}
+}
@@ -0,0 +1,204 @@
+package configuration
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Change is a value that differs between the previous and the reloaded config.
+type Change struct {
+	// Path is the dot separated MetaNode path of the value.
+	Path string `json:"path"`
+	// Env is the environment variable of the value, if it has one.
+	Env string `json:"env,omitempty"`
+	// Live is set if the running app applies the new value, see MetaNode.Live.
+	Live bool `json:"live"`
+}
+
+func (c Change) String() string {
+	if c.Env != "" {
+		return fmt.Sprintf("%s (%s)", c.Path, c.Env)
+	}
+	return c.Path
+}
+
+// ReloadResult describes a successful Reload.
+type ReloadResult struct {
+	Config AppConfig
+	// Changes are the changed values in the order of the config.
+	Changes []Change
+	// Warnings are the validation warnings of the reloaded config.
+	Warnings Problems
+}
+
+// String summarizes the changes, e.g. "2 changes, restart required for
+// server.http.port (APP_SERVER_HTTP_PORT)".
+func (r ReloadResult) String() string {
+	if len(r.Changes) == 0 {
+		return "no changes"
+	}
+
+	summary := fmt.Sprintf("%d changes", len(r.Changes))
+	if len(r.Changes) == 1 {
+		summary = "1 change"
+	}
+
+	restart := r.RestartRequired()
+	if len(restart) == 0 {
+		return summary + ", all applied"
+	}
+
+	names := make([]string, 0, len(restart))
+	for _, change := range restart {
+		names = append(names, change.String())
+	}
+	return summary + ", restart required for " + strings.Join(names, ", ")
+}
+
+// RestartRequired returns the changes that only apply after a restart.
+func (r ReloadResult) RestartRequired() []Change {
+	var changes []Change
+	for _, change := range r.Changes {
+		if !change.Live {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+type subscriber struct {
+	id     int
+	notify func(previous AppConfig, current AppConfig)
+}
+
+var (
+	subscribers      []subscriber
+	nextSubscriberID int
+	subscribersMutex sync.Mutex
+
+	// reloadMutex serializes the reloads, so subscribers see them in order.
+	reloadMutex sync.Mutex
+)
+
+// Subscribe registers notify to be called after every Reload that changed the
+// config. It returns a function removing the subscription.
+func Subscribe(notify func(previous AppConfig, current AppConfig)) func() {
+	subscribersMutex.Lock()
+	defer subscribersMutex.Unlock()
+
+	nextSubscriberID++
+	id := nextSubscriberID
+	subscribers = append(subscribers, subscriber{id: id, notify: notify})
+
+	return func() {
+		subscribersMutex.Lock()
+		defer subscribersMutex.Unlock()
+
+		for i := range subscribers {
+			if subscribers[i].id == id {
+				subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Reload loads and validates the app config again, like the first Get does.
+// If that succeeds the reloaded config is returned by Get from now on and
+// passed to the subscribers, otherwise the previous config is kept.
+func Reload() (ReloadResult, error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	previous, err := Get()
+	if err != nil {
+		return ReloadResult{}, err
+	}
+
+	cfg, err := load()
+	if err != nil {
+		return ReloadResult{}, fmt.Errorf("failed to reload app config: %w", err)
+	}
+
+	appConfigMutex.Lock()
+	loadedAppConfig = cfg
+	appConfigMutex.Unlock()
+
+	metaNodeMutex.Lock()
+	metaNodeLoaded = false
+	metaNodeMutex.Unlock()
+
+	result := ReloadResult{
+		Config:   cfg,
+		Changes:  diffConfigs(previous, cfg),
+		Warnings: cfg.Validate().Filter(SeverityWarning),
+	}
+
+	if len(result.Changes) > 0 {
+		subscribersMutex.Lock()
+		notified := append([]subscriber{}, subscribers...)
+		subscribersMutex.Unlock()
+
+		for _, subscriber := range notified {
+			subscriber.notify(previous, cfg)
+		}
+	}
+
+	return result, nil
+}
+
+// Live returns a pointer holding cfg that every Reload replaces with the
+// reloaded config. Handlers read the values tagged `reload:"live"` from it.
+func Live(cfg AppConfig) *atomic.Pointer[AppConfig] {
+	live := &atomic.Pointer[AppConfig]{}
+	live.Store(&cfg)
+	Subscribe(func(_ AppConfig, current AppConfig) {
+		live.Store(&current)
+	})
+	return live
+}
+
+// diffConfigs returns the values that differ between previous and current.
+func diffConfigs(previous AppConfig, current AppConfig) []Change {
+	leaves := func(cfg AppConfig) ([]string, map[string]MetaNode) {
+		var paths []string
+		nodes := make(map[string]MetaNode)
+		_ = createMetaNode([]string{}, "", cfg).Walk(func(node MetaNode) error {
+			if len(node.Children) == 0 && len(node.AbsolutePath) > 0 {
+				path := strings.Join(node.AbsolutePath, ".")
+				paths = append(paths, path)
+				nodes[path] = node
+			}
+			return nil
+		})
+		return paths, nodes
+	}
+
+	previousPaths, previousNodes := leaves(previous)
+	currentPaths, currentNodes := leaves(current)
+
+	var changes []Change
+	seen := make(map[string]bool)
+	for _, path := range append(currentPaths, previousPaths...) {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		before, hadBefore := previousNodes[path]
+		after, hasAfter := currentNodes[path]
+		if hadBefore && hasAfter && reflect.DeepEqual(before.Value, after.Value) {
+			continue
+		}
+
+		node := after
+		if !hasAfter {
+			node = before
+		}
+		changes = append(changes, Change{Path: path, Env: node.Env, Live: node.Live})
+	}
+	return changes
+}
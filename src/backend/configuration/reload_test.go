@@ -0,0 +1,182 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useTempDataDir runs the test in an empty directory with no config loaded.
+func useTempDataDir(t *testing.T) string {
+	t.Chdir(t.TempDir())
+
+	appConfigMutex.Lock()
+	previous, previousLoaded := loadedAppConfig, appConfigLoaded
+	appConfigLoaded = false
+	appConfigMutex.Unlock()
+
+	t.Cleanup(func() {
+		for key := range dotEnvApplied {
+			os.Unsetenv(key)
+			delete(dotEnvApplied, key)
+		}
+
+		appConfigMutex.Lock()
+		loadedAppConfig, appConfigLoaded = previous, previousLoaded
+		appConfigMutex.Unlock()
+
+		metaNodeMutex.Lock()
+		metaNodeLoaded = false
+		metaNodeMutex.Unlock()
+	})
+
+	return "pb_data"
+}
+
+func replaceInFile(t *testing.T, name string, old string, new string) {
+	t.Helper()
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read '%s': %v", name, err)
+	}
+	if !strings.Contains(string(data), old) {
+		t.Fatalf("expected '%s' to contain %q", name, old)
+	}
+	if err := os.WriteFile(name, []byte(strings.Replace(string(data), old, new, 1)), 0o644); err != nil {
+		t.Fatalf("failed to write '%s': %v", name, err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	pbData := useTempDataDir(t)
+	t.Setenv("APP_GENERAL_VERSION", "from-process")
+
+	initial, err := Get()
+	if err != nil {
+		t.Fatalf("failed to load the default config: %v", err)
+	}
+
+	var previousConfigs, notified []AppConfig
+	unsubscribe := Subscribe(func(previous AppConfig, current AppConfig) {
+		previousConfigs = append(previousConfigs, previous)
+		notified = append(notified, current)
+	})
+	defer unsubscribe()
+
+	configPath := filepath.Join(pbData, "app.config.jsonc")
+	replaceInFile(t, configPath, `"initialAdminRegistration": false`, `"initialAdminRegistration": true`)
+	replaceInFile(t, configPath, `"port": 8161`, `"port": 8162`)
+
+	dotEnv := filepath.Join(pbData, ".env")
+	if err := os.WriteFile(dotEnv, []byte("APP_GENERAL_NAME=Reloaded\nAPP_GENERAL_VERSION=from-dotenv\n"), 0o644); err != nil {
+		t.Fatalf("failed to write .env: %v", err)
+	}
+
+	result, err := Reload()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var paths []string
+	for _, change := range result.Changes {
+		paths = append(paths, change.String())
+	}
+	expected := "general.name (APP_GENERAL_NAME), general.initialAdminRegistration (APP_GENERAL_INITIAL_ADMIN_REGISTRATION), server.http.port (APP_SERVER_HTTP_PORT)"
+	if strings.Join(paths, ", ") != expected {
+		t.Fatalf("unexpected changes %v", paths)
+	}
+	if restart := result.RestartRequired(); len(restart) != 1 || restart[0].Path != "server.http.port" {
+		t.Errorf("expected only the port to need a restart, got %v", restart)
+	}
+
+	current, _ := Get()
+	if current.General.Name != "Reloaded" || current.General.Version != "from-process" || !current.General.InitialAdminRegistration {
+		t.Errorf("expected the reloaded values with the process environment kept, got %+v", current.General)
+	}
+	if len(notified) != 1 || notified[0].Server.HTTP.Port != 8162 || previousConfigs[0].Server.HTTP.Port != initial.Server.HTTP.Port {
+		t.Fatalf("expected the subscriber to get the previous and reloaded config once, got %d", len(notified))
+	}
+
+	// removed .env values fall back to the file
+	if err := os.WriteFile(dotEnv, nil, 0o644); err != nil {
+		t.Fatalf("failed to write .env: %v", err)
+	}
+	if _, err := Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current, _ := Get(); current.General.Name != initial.General.Name {
+		t.Errorf("expected the name of the file, got %q", current.General.Name)
+	}
+
+	replaceInFile(t, configPath, `"port": 8162`, `"port": "8163"`)
+	if _, err := Reload(); err == nil {
+		t.Fatal("expected an error reloading an invalid config")
+	}
+	if current, _ := Get(); current.Server.HTTP.Port != 8162 {
+		t.Errorf("expected the previous config to be kept, got port %d", current.Server.HTTP.Port)
+	}
+
+	if _, err := Reload(); err == nil || len(notified) != 2 {
+		t.Errorf("expected no notification for failed reloads, got %d", len(notified))
+	}
+}
+
+func TestReloadKeepsEnvironmentOnError(t *testing.T) {
+	pbData := useTempDataDir(t)
+
+	dotEnv := filepath.Join(pbData, ".env")
+	if _, err := Get(); err != nil {
+		t.Fatalf("failed to load the default config: %v", err)
+	}
+	if err := os.WriteFile(dotEnv, []byte("APP_GENERAL_NAME=Kept\n"), 0o644); err != nil {
+		t.Fatalf("failed to write .env: %v", err)
+	}
+	if _, err := Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a valid name with an invalid port is rejected as a whole
+	if err := os.WriteFile(dotEnv, []byte("APP_GENERAL_NAME=Rejected\nAPP_SERVER_HTTP_PORT=70000\n"), 0o644); err != nil {
+		t.Fatalf("failed to write .env: %v", err)
+	}
+	if _, err := Reload(); err == nil {
+		t.Fatal("expected an error reloading an invalid .env")
+	}
+
+	if name := os.Getenv("APP_GENERAL_NAME"); name != "Kept" {
+		t.Errorf("expected the name of the previous .env, got %q", name)
+	}
+	if _, set := os.LookupEnv("APP_SERVER_HTTP_PORT"); set {
+		t.Error("expected the rejected port not to be set")
+	}
+	if current, _ := Get(); current.General.Name != "Kept" {
+		t.Errorf("expected the previous config to be kept, got %q", current.General.Name)
+	}
+
+	// unsetting the name is undone as well
+	if err := os.WriteFile(dotEnv, []byte("APP_SERVER_HTTP_PORT=70000\n"), 0o644); err != nil {
+		t.Fatalf("failed to write .env: %v", err)
+	}
+	if _, err := Reload(); err == nil {
+		t.Fatal("expected an error reloading an invalid .env")
+	}
+	if name := os.Getenv("APP_GENERAL_NAME"); name != "Kept" {
+		t.Errorf("expected the name of the previous .env, got %q", name)
+	}
+}
+
+func TestReloadResultString(t *testing.T) {
+	result := ReloadResult{Changes: []Change{
+		{Path: "general.name", Env: "APP_GENERAL_NAME", Live: true},
+		{Path: "server.http.port", Env: "APP_SERVER_HTTP_PORT"},
+	}}
+	if s := result.String(); s != "2 changes, restart required for server.http.port (APP_SERVER_HTTP_PORT)" {
+		t.Errorf("unexpected summary %q", s)
+	}
+
+	if s := (ReloadResult{Changes: result.Changes[:1]}).String(); s != "1 change, all applied" {
+		t.Errorf("unexpected summary %q", s)
+	}
+}
@@ -0,0 +1,273 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDraft is the JSON Schema version of the generated schema, the one
+// most editors support.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is the subset of JSON Schema describing AppConfig.
+type JSONSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Type is a single type name or a list of them, e.g. for nullable values.
+	Type any `json:"type,omitempty"`
+
+	Properties map[string]*JSONSchema `json:"properties,omitempty"`
+
+	// AdditionalProperties is false for structs and the schema of the values
+	// for maps.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+
+	Items   *JSONSchema `json:"items,omitempty"`
+	Default any         `json:"default,omitempty"`
+}
+
+// AppConfigSchema returns the JSON Schema of AppConfig, generated from its
+// struct tags: descriptions from `env-description` and defaults from
+// `env-default`.
+func AppConfigSchema() *JSONSchema {
+	schema := nodeSchema(createMetaNode([]string{}, "", AppConfig{}))
+	schema.Schema = JSONSchemaDraft
+	schema.Title = "app.config.jsonc"
+
+	// lets editors find the schema without any settings
+	schema.Properties["$schema"] = &JSONSchema{Type: "string"}
+
+	return schema
+}
+
+func nodeSchema(node MetaNode) *JSONSchema {
+	schema := &JSONSchema{
+		Type:                 "object",
+		Properties:           make(map[string]*JSONSchema, len(node.Children)),
+		AdditionalProperties: false,
+	}
+
+	for _, child := range node.Children {
+		schema.Properties[child.Name] = fieldSchema(child)
+	}
+	return schema
+}
+
+func fieldSchema(node MetaNode) *JSONSchema {
+	var schema *JSONSchema
+	if len(node.Children) > 0 {
+		schema = nodeSchema(node)
+	} else {
+		schema = typeSchema(node.Type)
+	}
+
+	schema.Description = node.Description
+	if node.EnvDefault != "" {
+		schema.Default = defaultValue(node.Type, node.EnvDefault, node.EnvSeparator)
+	}
+	return schema
+}
+
+// typeSchema returns the schema of the JSON encoding of typ. Structs reached
+// through pointers, slices and maps are described by their MetaNode.
+func typeSchema(typ reflect.Type) *JSONSchema {
+	if typ == nil {
+		return &JSONSchema{}
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer:
+		schema := typeSchema(typ.Elem())
+		if name, ok := schema.Type.(string); ok {
+			schema.Type = []string{name, "null"}
+		}
+		return schema
+	case reflect.Struct:
+		return nodeSchema(createMetaNode([]string{}, "", reflect.Zero(typ).Interface()))
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: []string{"array", "null"}, Items: typeSchema(typ.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: []string{"object", "null"}, AdditionalProperties: typeSchema(typ.Elem())}
+	default:
+		return &JSONSchema{}
+	}
+}
+
+// defaultValue converts the `env-default` tag to the JSON value of typ. It
+// returns nil for defaults that do not parse.
+func defaultValue(typ reflect.Type, value string, separator string) any {
+	if typ == nil {
+		return nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return defaultValue(typ.Elem(), value, separator)
+	case reflect.String:
+		return value
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case reflect.Slice:
+		if separator == "" {
+			separator = ","
+		}
+		values := []any{}
+		for _, item := range strings.Split(value, separator) {
+			if v := defaultValue(typ.Elem(), strings.TrimSpace(item), ""); v != nil {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// types returns the type names allowed by the schema, nil for any type.
+func (s *JSONSchema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+// Validate checks the JSON document data against the schema and reports every
+// unknown key and type mismatch with the path of the offending value, e.g.
+// "server.http.port: expected integer, got string".
+func (s *JSONSchema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	return errors.Join(s.validate("", value)...)
+}
+
+func (s *JSONSchema) validate(path string, value any) []error {
+	actual := jsonTypeName(value)
+	if types := s.types(); types != nil && !slices.Contains(types, actual) &&
+		!(actual == "integer" && slices.Contains(types, "number")) {
+		return []error{fmt.Errorf("%s: expected %s, got %s", schemaPath(path), strings.Join(types, " or "), actual)}
+	}
+
+	var errs []error
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+
+			if property, ok := s.Properties[key]; ok {
+				errs = append(errs, property.validate(childPath, v[key])...)
+				continue
+			}
+
+			switch additional := s.AdditionalProperties.(type) {
+			case *JSONSchema:
+				errs = append(errs, additional.validate(childPath, v[key])...)
+			case bool:
+				if !additional {
+					errs = append(errs, fmt.Errorf("%s: unknown key", childPath))
+				}
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	}
+	return errs
+}
+
+func schemaPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+func jsonTypeName(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// WriteJSONSchema writes the AppConfigSchema to path, unless the file is
+// already up to date. It reports whether it was written.
+func WriteJSONSchema(path string) (bool, error) {
+	schema, err := json.MarshalIndent(AppConfigSchema(), "", "  ")
+	if err != nil {
+		return false, fmt.Errorf("failed to encode config schema: %w", err)
+	}
+	schema = append(schema, '\n')
+
+	existing, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(existing, schema) {
+		return false, nil
+	}
+
+	if err := os.WriteFile(path, schema, 0o644); err != nil {
+		return false, fmt.Errorf("failed to write config schema '%s': %w", path, err)
+	}
+	return true, nil
+}
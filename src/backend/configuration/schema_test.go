@@ -0,0 +1,80 @@
+package configuration
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAppConfigSchema(t *testing.T) {
+	schema := AppConfigSchema()
+
+	port := schema.Properties["server"].Properties["http"].Properties["port"]
+	if port.Type != "integer" || port.Default != int64(8161) || port.Description == "" {
+		t.Fatalf("expected an integer port with default and description, got %+v", port)
+	}
+
+	directives := schema.Properties["server"].Properties["security"].Properties["cspDirectives"]
+	if defaults, ok := directives.Default.([]any); !ok || len(defaults) != 8 || defaults[0] != "default-src 'self'" {
+		t.Fatalf("expected the CSP directives split by their separator, got %#v", directives.Default)
+	}
+
+	encryptionKey := schema.Properties["server"].Properties["encryptionKey"]
+	if !reflect.DeepEqual(encryptionKey.Type, []string{"string", "null"}) {
+		t.Fatalf("expected a nullable encryption key, got %#v", encryptionKey.Type)
+	}
+
+	rule := schema.Properties["server"].Properties["staticCache"].Properties["rules"].Items
+	if rule == nil || rule.Properties["maxAge"].Type != "integer" || rule.AdditionalProperties != false {
+		t.Fatalf("expected the rules to be described by their struct, got %+v", rule)
+	}
+}
+
+func TestParseAppConfigRejectsInvalidConfig(t *testing.T) {
+	valid := `{
+		"$schema": "./app.config.schema.json",
+		"server": {
+			"http": {"port": 8080},
+			"virtualHosts": [{"hosts": ["www.example.com"], "staticCache": null, "htmlVars": {"X": "y"}}]
+		}
+	}`
+	if _, err := ParseAppConfig([]byte(valid)); err != nil {
+		t.Fatalf("unexpected error parsing a valid config: %v", err)
+	}
+
+	invalid := `{
+		"server": {
+			"indexFalback": false,
+			"http": {"port": "8080"},
+			"staticCache": {"rules": [{"glob": "*.js", "maxAge": "1d"}]},
+			"virtualHosts": [{"hosts": "www.example.com"}]
+		}
+	}`
+	_, err := ParseAppConfig([]byte(invalid))
+	if err == nil {
+		t.Fatal("expected an error parsing an invalid config")
+	}
+
+	for _, expected := range []string{
+		"server.indexFalback: unknown key",
+		"server.http.port: expected integer, got string",
+		"server.staticCache.rules[0].maxAge: expected integer, got string",
+		"server.virtualHosts[0].hosts: expected array or null, got string",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected the error to contain %q, got:\n%v", expected, err)
+		}
+	}
+}
+
+func TestWriteJSONSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.config.schema.json")
+
+	if written, err := WriteJSONSchema(path); err != nil || !written {
+		t.Fatalf("expected the schema to be written, got %v and error %v", written, err)
+	}
+	if written, err := WriteJSONSchema(path); err != nil || written {
+		t.Fatalf("expected an up to date schema to be kept, got %v and error %v", written, err)
+	}
+}
@@ -0,0 +1,199 @@
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Severity tells whether a Problem prevents the app from starting.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is a single finding of AppConfig.Validate.
+type Problem struct {
+	Severity Severity
+	// Path is the dot separated MetaNode path of the value that caused the
+	// problem, e.g. "server.https.port".
+	Path string
+	// Env is the environment variable of the value, if it has one.
+	Env     string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Env != "" {
+		return fmt.Sprintf("%s: %s (%s): %s", p.Severity, p.Path, p.Env, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Severity, p.Path, p.Message)
+}
+
+// Problems are all findings of AppConfig.Validate.
+type Problems []Problem
+
+// Filter returns the problems of the given severity.
+func (p Problems) Filter(severity Severity) Problems {
+	var filtered Problems
+	for _, problem := range p {
+		if problem.Severity == severity {
+			filtered = append(filtered, problem)
+		}
+	}
+	return filtered
+}
+
+// Err joins the problems of severity error, it is nil if there are none.
+func (p Problems) Err() error {
+	var errs []error
+	for _, problem := range p.Filter(SeverityError) {
+		errs = append(errs, errors.New(problem.String()))
+	}
+	return errors.Join(errs...)
+}
+
+// Report collects the problems found by the validators.
+type Report struct {
+	nodes    map[string]MetaNode
+	problems Problems
+}
+
+// Error reports a problem with the value at path that prevents starting.
+func (r *Report) Error(path string, format string, args ...any) {
+	r.add(SeverityError, path, fmt.Sprintf(format, args...))
+}
+
+// Warning reports a questionable value at path.
+func (r *Report) Warning(path string, format string, args ...any) {
+	r.add(SeverityWarning, path, fmt.Sprintf(format, args...))
+}
+
+func (r *Report) add(severity Severity, path string, message string) {
+	r.problems = append(r.problems, Problem{
+		Severity: severity,
+		Path:     path,
+		Env:      r.nodes[path].Env,
+		Message:  message,
+	})
+}
+
+// Validator checks one aspect of the config and reports its problems.
+type Validator func(cfg AppConfig, report *Report)
+
+type namedValidator struct {
+	name     string
+	validate Validator
+}
+
+var (
+	validators = []namedValidator{
+		{name: "servers", validate: validateServers},
+		{name: "encryptionKey", validate: validateEncryptionKey},
+		{name: "url", validate: validateURL},
+		{name: "email", validate: validateEmail},
+	}
+	validatorsMutex sync.Mutex
+)
+
+// RegisterValidator adds a validator run by AppConfig.Validate, replacing an
+// earlier validator with the same name.
+func RegisterValidator(name string, validate Validator) {
+	validatorsMutex.Lock()
+	defer validatorsMutex.Unlock()
+
+	for i := range validators {
+		if validators[i].name == name {
+			validators[i].validate = validate
+			return
+		}
+	}
+	validators = append(validators, namedValidator{name: name, validate: validate})
+}
+
+// Validate runs all registered validators and returns every problem found,
+// in the order of the validators.
+func (cfg AppConfig) Validate() Problems {
+	report := &Report{nodes: make(map[string]MetaNode)}
+	_ = createMetaNode([]string{}, "", cfg).Walk(func(node MetaNode) error {
+		report.nodes[strings.Join(node.AbsolutePath, ".")] = node
+		return nil
+	})
+
+	validatorsMutex.Lock()
+	registered := append([]namedValidator{}, validators...)
+	validatorsMutex.Unlock()
+
+	for _, validator := range registered {
+		validator.validate(cfg, report)
+	}
+	return report.problems
+}
+
+func validateServers(cfg AppConfig, report *Report) {
+	http, https := cfg.Server.HTTP, cfg.Server.HTTPS
+
+	if !http.Enabled && !https.Enabled {
+		report.Error("server.http.enabled", "neither the HTTP nor the HTTPS server is enabled")
+	}
+
+	if http.Enabled && (http.Port < 1 || http.Port > 65535) {
+		report.Error("server.http.port", "port %d is out of range", http.Port)
+	}
+	if https.Enabled && (https.Port < 1 || https.Port > 65535) {
+		report.Error("server.https.port", "port %d is out of range", https.Port)
+	}
+
+	if http.Enabled && https.Enabled && http.Port == https.Port && overlappingAddresses(http.Address, https.Address) {
+		report.Error("server.https.port", "port %d is already used by the HTTP server", https.Port)
+	}
+
+	if len(cfg.Server.Domains) > 0 && !https.Enabled {
+		report.Warning("server.domains", "certificates are only issued if the HTTPS server is enabled")
+	}
+}
+
+// overlappingAddresses reports whether two listen addresses may bind the same
+// interface, which is the case for equal and unspecified addresses.
+func overlappingAddresses(a string, b string) bool {
+	unspecified := func(address string) bool {
+		ip := net.ParseIP(address)
+		return address == "" || (ip != nil && ip.IsUnspecified())
+	}
+	return a == b || unspecified(a) || unspecified(b)
+}
+
+func validateEncryptionKey(cfg AppConfig, report *Report) {
+	if key := cfg.Server.EncryptionKey; key != nil && *key != "" && len(*key) != 32 {
+		report.Error("server.encryptionKey", "expected 32 characters, got %d", len(*key))
+	}
+}
+
+func validateURL(cfg AppConfig, report *Report) {
+	if cfg.General.URL == "" {
+		report.Warning("general.url", "no URL set, links in emails will not work")
+		return
+	}
+
+	parsed, err := url.Parse(cfg.General.URL)
+	if err != nil {
+		report.Error("general.url", "invalid URL: %v", err)
+		return
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		report.Error("general.url", "expected an absolute http or https URL, got '%s'", cfg.General.URL)
+	}
+}
+
+func validateEmail(cfg AppConfig, report *Report) {
+	address, err := mail.ParseAddress(cfg.Server.Email.SenderAddress)
+	if err != nil || address.Address != cfg.Server.Email.SenderAddress {
+		report.Error("server.email.senderAddress", "invalid email address '%s'", cfg.Server.Email.SenderAddress)
+	}
+}
@@ -0,0 +1,80 @@
+package configuration
+
+import (
+	"strings"
+	"testing"
+)
+
+func validAppConfig() AppConfig {
+	return AppConfig{
+		General: GeneralConfig{URL: "https://example.com/"},
+		Server: ServerConfig{
+			HTTP:  HTTPConfig{Address: "0.0.0.0", Port: 8161, Enabled: true},
+			HTTPS: HTTPSConfig{Address: "0.0.0.0", Port: 8443},
+			Email: EmailConfig{SenderAddress: "app@example.com"},
+		},
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	if problems := validAppConfig().Validate(); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+
+	cfg := validAppConfig()
+	key := "too short"
+	cfg.General.URL = "example.com"
+	cfg.Server.HTTPS = HTTPSConfig{Address: "127.0.0.1", Port: 8161, Enabled: true}
+	cfg.Server.EncryptionKey = &key
+	cfg.Server.Email.SenderAddress = "not an address"
+
+	expected := []Problem{
+		{Severity: SeverityError, Path: "server.https.port", Env: "APP_SERVER_HTTPS_PORT", Message: "port 8161 is already used by the HTTP server"},
+		{Severity: SeverityError, Path: "server.encryptionKey", Env: "APP_SERVER_ENCRYPTION_KEY", Message: "expected 32 characters, got 9"},
+		{Severity: SeverityError, Path: "general.url", Env: "APP_GENERAL_URL", Message: "expected an absolute http or https URL, got 'example.com'"},
+		{Severity: SeverityError, Path: "server.email.senderAddress", Env: "APP_SERVER_EMAIL_SENDER_ADDRESS", Message: "invalid email address 'not an address'"},
+	}
+
+	problems := cfg.Validate()
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %v", len(expected), problems)
+	}
+	for i := range expected {
+		if problems[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], problems[i])
+		}
+	}
+
+	err := problems.Err()
+	if err == nil || !strings.Contains(err.Error(), "error: server.encryptionKey (APP_SERVER_ENCRYPTION_KEY): expected 32 characters") {
+		t.Fatalf("expected the joined errors, got %v", err)
+	}
+
+	cfg = validAppConfig()
+	cfg.Server.HTTP.Enabled = false
+	cfg.Server.Domains = []string{"example.com"}
+	problems = cfg.Validate()
+	if len(problems.Filter(SeverityError)) != 1 || problems[0].Path != "server.http.enabled" {
+		t.Fatalf("expected an error for disabled servers, got %v", problems)
+	}
+	if warnings := problems.Filter(SeverityWarning); len(warnings) != 1 || warnings[0].Path != "server.domains" {
+		t.Fatalf("expected a warning for domains without HTTPS, got %v", warnings)
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	RegisterValidator("test", func(cfg AppConfig, report *Report) {
+		if cfg.General.Name == "" {
+			report.Warning("general.name", "no name set")
+		}
+	})
+	defer RegisterValidator("test", func(AppConfig, *Report) {})
+
+	problems := validAppConfig().Validate()
+	if len(problems) != 1 || problems[0].Env != "APP_GENERAL_NAME" || problems[0].Severity != SeverityWarning {
+		t.Fatalf("expected the warning of the registered validator, got %v", problems)
+	}
+	if problems.Err() != nil {
+		t.Fatalf("expected warnings not to be errors, got %v", problems.Err())
+	}
+}
@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// maxHashedFileSize is the size up to which files are tagged by the hash of
+// their content. Larger files are tagged by their size and modification time,
+// so serving them does not mean reading them once more.
+const maxHashedFileSize = 8 << 20
+
+// contentETag returns a strong entity tag for data.
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hashETag(sum[:])
+}
+
+func hashETag(sum []byte) string {
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// entityTag returns the strong entity tag of rep or an empty string if the
+// representation should only be revalidated by its modification time. Files
+// of mutable filesystems are not hashed, so large media in pb_public is not
+// read twice on every request.
+func (f *FSList) entityTag(rep representation) string {
+	etag := rep.item.etag
+
+	if etag == "" && rep.item.immutable && rep.item.fs != nil {
+		etag = f.fileETag(rep.item.fs, rep.name)
+		if etag == "" {
+			return ""
+		}
+
+		f.updateCached(rep.name, func(cached *FSCacheItem) {
+			cached.etag = etag
+		})
+	}
+
+	if etag == "" || rep.encoding == "" {
+		return etag
+	}
+
+	// Every content coding is a representation of its own and needs a distinct tag.
+	return strings.TrimSuffix(etag, `"`) + "-" + rep.encoding + `"`
+}
+
+// fileETag returns the entity tag of the file name of fsys, streaming it
+// through the hash, or an empty string if it cannot be read.
+func (f *FSList) fileETag(fsys fs.FS, name string) string {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		return ""
+	}
+
+	if info.Size() > maxHashedFileSize {
+		// same fallback for embedded files as the Last-Modified header
+		modTime := info.ModTime()
+		if modTime.IsZero() {
+			modTime = f.createdAt
+		}
+		return `"` + strconv.FormatInt(modTime.UnixNano(), 36) + "-" + strconv.FormatInt(info.Size(), 36) + `"`
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return ""
+	}
+	return hashETag(h.Sum(nil))
+}
+
+// etagWeakMatch reports whether the If-None-Match header value matches etag
+// by the weak comparison of RFC 9110, which ignores the "W/" prefixes.
+func etagWeakMatch(ifNoneMatch string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package backend
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/tools/router"
+)
+
+// normalizePathPrefixes makes every prefix absolute, so they can be compared
+// against request paths.
+func normalizePathPrefixes(prefixes []string) []string {
+	normalized := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		if !strings.HasPrefix(prefix, "/") {
+			prefix = "/" + prefix
+		}
+		normalized = append(normalized, prefix)
+	}
+	return normalized
+}
+
+// hasPathPrefix reports whether the name lies below one of the prefixes. A
+// prefix with a trailing slash also matches the directory itself.
+func hasPathPrefix(name string, prefixes []string) bool {
+	urlPath := "/" + strings.TrimPrefix(name, "/")
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(urlPath, prefix) || urlPath == strings.TrimSuffix(prefix, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// indexFallback reports whether the missing name is answered with the SPA
+// index. Only navigations fall back: names below one of the fallback routes,
+// names without a file extension or with an HTML one, and requests accepting
+// HTML. Excluded prefixes never fall back, so broken asset links surface as
+// real 404s instead of HTML with status 200.
+func (f *FSList) indexFallback(name string, accept string) bool {
+	if !f.cfg.Server.IndexFallback || name == router.IndexPage {
+		return false
+	}
+
+	if hasPathPrefix(name, f.fallbackExclude) {
+		return false
+	}
+
+	if hasPathPrefix(name, f.fallbackRoutes) {
+		return true
+	}
+
+	switch strings.ToLower(path.Ext(name)) {
+	case "", ".html", ".htm":
+		return true
+	}
+
+	return strings.Contains(accept, "text/html")
+}
+
+// openRequested opens the representation of name, falling back to the SPA
+// index if the request qualifies for it. Such a representation is marked as
+// a fallback. The redirects file is treated as missing, it configures the
+// server and is not part of the site.
+func (f *FSList) openRequested(r *http.Request, name string, acceptEncoding string) (representation, error) {
+	var rep representation
+	var err error
+	if f.redirectsFile != "" && name == f.redirectsFile {
+		err = fs.ErrNotExist
+	} else {
+		rep, err = f.openRepresentation(name, acceptEncoding)
+	}
+	if err != nil && f.indexFallback(name, r.Header.Get("Accept")) {
+		rep, err = f.openRepresentation(router.IndexPage, acceptEncoding)
+		rep.fallback = true
+	}
+	return rep, err
+}
+
+// serveNotFound answers with the configured 404 page and status 404. Without
+// such a page the request is left to the PocketBase error handler.
+func (f *FSList) serveNotFound(w http.ResponseWriter, r *http.Request, acceptEncoding string) error {
+	if f.notFoundPage == "" {
+		return router.ErrFileNotFound
+	}
+
+	served, err := f.servePage(w, r, f.notFoundPage, acceptEncoding, http.StatusNotFound)
+	if !served {
+		return router.ErrFileNotFound
+	}
+	return err
+}
+
+// servePage answers with the static page name and status. It reports false
+// without writing anything if there is no such page.
+func (f *FSList) servePage(w http.ResponseWriter, r *http.Request, name string, acceptEncoding string, status int) (bool, error) {
+	rep, err := f.openRepresentation(name, acceptEncoding)
+	if err != nil {
+		return false, nil
+	}
+	defer rep.file.Close()
+
+	info, err := rep.file.Stat()
+	if err != nil || info.IsDir() {
+		return false, nil
+	}
+
+	if f.dynamicHTML(rep) {
+		return true, f.serveDynamicHTML(w, r, rep, nil, status)
+	}
+	return true, writeWithStatus(w, r, rep, status)
+}
+
+// writeWithStatus writes rep with a status other than 200. Unlike
+// http.ServeContent it does not answer conditional or range requests, as
+// those only apply to successful responses.
+func writeWithStatus(w http.ResponseWriter, r *http.Request, rep representation, status int) error {
+	contentType := mime.TypeByExtension(filepath.Ext(rep.name))
+	if contentType == "" {
+		contentType = "text/html; charset=utf-8"
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	if isCompressible(rep.name) {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if rep.encoding != "" {
+		header.Set("Content-Encoding", rep.encoding)
+	}
+	header.Set("Cache-Control", "no-cache")
+
+	w.WriteHeader(status)
+
+	if r.Method != http.MethodHead {
+		_, err := io.Copy(w, rep.file)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package backend
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// fsCacheEntryOverhead approximates the memory of an entry besides its
+// contents (map slot, list element, file info), so entries that only point
+// to a filesystem are not free.
+const fsCacheEntryOverhead = 256
+
+// FSCacheStats is a snapshot of the FSList cache counters.
+type FSCacheStats struct {
+	Hits            uint64 `json:"hits"`
+	Misses          uint64 `json:"misses"`
+	Evictions       uint64 `json:"evictions"`
+	Expirations     uint64 `json:"expirations"`
+	Entries         int    `json:"entries"`
+	NegativeEntries int    `json:"negativeEntries"`
+	Bytes           int64  `json:"bytes"`
+	MaxEntries      int    `json:"maxEntries"`
+	MaxBytes        int64  `json:"maxBytes"`
+}
+
+type fsCacheEntry struct {
+	name      string
+	item      FSCacheItem
+	size      int64
+	expiresAt time.Time
+}
+
+// fsCache is a size and entry bounded LRU of FSCacheItems. Negative entries
+// (cached lookup errors) live in a separate list with its own bound and TTL,
+// so requests for random paths cannot push out the files actually served.
+type fsCache struct {
+	mutex sync.Mutex
+
+	maxEntries         int
+	maxBytes           int64
+	negativeMaxEntries int
+	negativeTTL        time.Duration
+
+	index    map[string]*list.Element
+	entries  *list.List
+	negative *list.List
+	bytes    int64
+
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+
+	now func() time.Time
+}
+
+func newFSCache(cfg configuration.FileCacheConfig) *fsCache {
+	return &fsCache{
+		maxEntries:         max(cfg.MaxEntries, 0),
+		maxBytes:           int64(max(cfg.MaxMemoryMB, 0)) * 1024 * 1024,
+		negativeMaxEntries: max(cfg.NegativeMaxEntries, 0),
+		negativeTTL:        time.Duration(max(cfg.NegativeTTLSeconds, 0)) * time.Second,
+		index:              make(map[string]*list.Element),
+		entries:            list.New(),
+		negative:           list.New(),
+		now:                time.Now,
+	}
+}
+
+func (item FSCacheItem) memorySize(name string) int64 {
+	size := int64(fsCacheEntryOverhead + len(name) + len(item.content) + len(item.etag))
+	for coding, variant := range item.encoded {
+		size += int64(len(coding) + len(variant.name) + len(variant.content))
+	}
+	return size
+}
+
+func (c *fsCache) get(name string) (FSCacheItem, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.index[name]
+	if !ok {
+		c.misses++
+		return FSCacheItem{}, false
+	}
+
+	entry := element.Value.(*fsCacheEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		c.expirations++
+		c.misses++
+		return FSCacheItem{}, false
+	}
+
+	c.listOf(entry).MoveToFront(element)
+	c.hits++
+
+	return entry.item, true
+}
+
+func (c *fsCache) set(name string, item FSCacheItem) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.index[name]; ok {
+		c.remove(element)
+	}
+
+	entry := &fsCacheEntry{name: name, item: item, size: item.memorySize(name)}
+	if item.err != nil && c.negativeTTL > 0 {
+		entry.expiresAt = c.now().Add(c.negativeTTL)
+	}
+
+	// An item that can never fit would only flush the whole cache.
+	if c.maxBytes > 0 && entry.size > c.maxBytes {
+		return
+	}
+
+	c.index[name] = c.listOf(entry).PushFront(entry)
+	c.bytes += entry.size
+
+	c.evict()
+}
+
+// update applies fn to the cached item of name, if there is one.
+func (c *fsCache) update(name string, fn func(item *FSCacheItem)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.index[name]
+	if !ok {
+		return
+	}
+
+	entry := element.Value.(*fsCacheEntry)
+	fn(&entry.item)
+
+	c.bytes -= entry.size
+	entry.size = entry.item.memorySize(name)
+	c.bytes += entry.size
+
+	if c.maxBytes > 0 && entry.size > c.maxBytes {
+		c.remove(element)
+		c.evictions++
+		return
+	}
+
+	c.evict()
+}
+
+// invalidate drops the entries of names and of everything below them. An
+// empty name drops all entries.
+func (c *fsCache) invalidate(names ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, name := range names {
+		if name == "" {
+			for _, element := range c.index {
+				c.remove(element)
+			}
+			return
+		}
+
+		if element, ok := c.index[name]; ok {
+			c.remove(element)
+		}
+
+		prefix := name + "/"
+		for cachedName, element := range c.index {
+			if strings.HasPrefix(cachedName, prefix) {
+				c.remove(element)
+			}
+		}
+	}
+}
+
+func (c *fsCache) stats() FSCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return FSCacheStats{
+		Hits:            c.hits,
+		Misses:          c.misses,
+		Evictions:       c.evictions,
+		Expirations:     c.expirations,
+		Entries:         c.entries.Len(),
+		NegativeEntries: c.negative.Len(),
+		Bytes:           c.bytes,
+		MaxEntries:      c.maxEntries,
+		MaxBytes:        c.maxBytes,
+	}
+}
+
+func (c *fsCache) listOf(entry *fsCacheEntry) *list.List {
+	if entry.item.err != nil {
+		return c.negative
+	}
+	return c.entries
+}
+
+func (c *fsCache) remove(element *list.Element) {
+	entry := element.Value.(*fsCacheEntry)
+	c.listOf(entry).Remove(element)
+	delete(c.index, entry.name)
+	c.bytes -= entry.size
+}
+
+// evict drops the least recently used entries until all bounds are met.
+func (c *fsCache) evict() {
+	for c.negativeMaxEntries > 0 && c.negative.Len() > c.negativeMaxEntries {
+		c.remove(c.negative.Back())
+		c.evictions++
+	}
+
+	for c.entries.Len() > 0 &&
+		((c.maxEntries > 0 && c.entries.Len() > c.maxEntries) ||
+			(c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.remove(c.entries.Back())
+		c.evictions++
+	}
+
+	for c.maxBytes > 0 && c.bytes > c.maxBytes && c.negative.Len() > 0 {
+		c.remove(c.negative.Back())
+		c.evictions++
+	}
+}
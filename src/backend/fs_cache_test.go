@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestFSCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newFSCache(configuration.FileCacheConfig{MaxEntries: 2})
+
+	cache.set("a", FSCacheItem{content: []byte("a")})
+	cache.set("b", FSCacheItem{content: []byte("b")})
+
+	if _, ok := cache.get("a"); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+
+	cache.set("c", FSCacheItem{content: []byte("c")})
+
+	if _, ok := cache.get("b"); ok {
+		t.Fatalf("expected least recently used entry b to be evicted")
+	}
+	if _, ok := cache.get("a"); !ok {
+		t.Fatalf("expected recently used entry a to survive")
+	}
+
+	stats := cache.stats()
+	if stats.Entries != 2 || stats.Evictions != 1 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestFSCacheBoundsMemory(t *testing.T) {
+	cache := newFSCache(configuration.FileCacheConfig{MaxMemoryMB: 1})
+	chunk := make([]byte, 400*1024)
+
+	cache.set("a", FSCacheItem{content: chunk})
+	cache.set("b", FSCacheItem{content: chunk})
+	cache.set("c", FSCacheItem{content: chunk})
+
+	stats := cache.stats()
+	if stats.Entries != 2 || stats.Bytes > stats.MaxBytes {
+		t.Fatalf("expected memory bound to evict one entry, got %+v", stats)
+	}
+
+	cache.set("huge", FSCacheItem{content: make([]byte, 2*1024*1024)})
+	if _, ok := cache.get("huge"); ok {
+		t.Fatalf("expected item larger than the cache to be skipped")
+	}
+
+	cache.update("b", func(item *FSCacheItem) {
+		item.encoded = map[string]encodedVariant{"gzip": {content: chunk}}
+	})
+	if stats := cache.stats(); stats.Bytes > stats.MaxBytes {
+		t.Fatalf("expected update to respect memory bound, got %+v", stats)
+	}
+}
+
+func TestFSCacheNegativeEntries(t *testing.T) {
+	cache := newFSCache(configuration.FileCacheConfig{MaxEntries: 1, NegativeMaxEntries: 2, NegativeTTLSeconds: 30})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.set("index.html", FSCacheItem{content: []byte("index")})
+	for _, name := range []string{"missing-1", "missing-2", "missing-3"} {
+		cache.set(name, FSCacheItem{err: fs.ErrNotExist})
+	}
+
+	if _, ok := cache.get("index.html"); !ok {
+		t.Fatalf("expected negative entries not to evict positive entries")
+	}
+	if _, ok := cache.get("missing-1"); ok {
+		t.Fatalf("expected oldest negative entry to be evicted")
+	}
+
+	item, ok := cache.get("missing-3")
+	if !ok || !errors.Is(item.err, fs.ErrNotExist) {
+		t.Fatalf("expected cached lookup error, got %v (%v)", item.err, ok)
+	}
+
+	now = now.Add(31 * time.Second)
+	if _, ok := cache.get("missing-3"); ok {
+		t.Fatalf("expected negative entry to expire")
+	}
+
+	stats := cache.stats()
+	if stats.Expirations != 1 || stats.NegativeEntries != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
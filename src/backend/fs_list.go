@@ -2,12 +2,14 @@ package backend
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pocketbase/pocketbase/tools/router"
@@ -17,23 +19,96 @@ import (
 type FSItem struct {
 	fs        fs.FS
 	immutable bool
+
+	// root is the directory fs was created from with os.DirFS, so it can be
+	// watched for changes. It is empty for other filesystems.
+	root string
+}
+
+// newDirFSItem returns a mutable FSItem serving the directory dir.
+func newDirFSItem(dir string) FSItem {
+	return FSItem{fs: os.DirFS(dir), root: dir}
 }
 
 type FSCacheItem struct {
-	fs      fs.FS
-	content []byte
-	info    fs.FileInfo
-	err     error
+	fs        fs.FS
+	content   []byte
+	info      fs.FileInfo
+	err       error
+	immutable bool
+
+	// etag is the strong entity tag of the identity representation. It is
+	// computed once for immutable and transformed files and empty otherwise.
+	etag string
+
+	// encoded holds the precompressed representations keyed by content coding.
+	// It is nil until the variants have been looked up for the first time.
+	encoded map[string]encodedVariant
 }
 
 type FSList struct {
-	devMode      bool
-	cfg          configuration.AppConfig
-	filesystems  []FSItem
-	cache        map[string]FSCacheItem
-	cacheMutex   sync.RWMutex
-	htmlVarMap   map[string]string
-	htmlReplacer *strings.Replacer
+	createdAt   time.Time
+	devMode     bool
+	cfg         configuration.AppConfig
+	basePath    string
+	filesystems []FSItem
+	cache       *fsCache
+	html        *atomic.Pointer[htmlTransform]
+	cachePolicy cachePolicy
+	liveReload  bool
+
+	// viteProxy is set if one of the filesystems is a Vite dev server.
+	viteProxy *viteProxy
+
+	fallbackRoutes  []string
+	fallbackExclude []string
+	notFoundPage    string
+	maintenancePage string
+
+	// redirects are evaluated in order before the static files are looked up.
+	redirects []redirectRule
+	// redirectsFile is the name of the redirects file, which is not served.
+	redirectsFile string
+
+	// routes is the route manifest of the frontend, nil if there is none.
+	routes *routeManifest
+	meta   *MetaRegistry
+
+	security securityHeaders
+
+	// integrityDigests is set if script and stylesheet elements of HTML pages
+	// get Subresource Integrity attributes.
+	integrityDigests *integrityDigests
+}
+
+// htmlTransform holds the config values HTML pages are transformed with. It
+// is replaced as a whole when the config is reloaded.
+type htmlTransform struct {
+	replacer *strings.Replacer
+
+	// publicConfigScript sets window.__APP_CONFIG__ in every HTML page, it is
+	// nil if the public config is not injected.
+	publicConfigScript []byte
+
+	// loadedAt is when the values were loaded, transformed pages are never
+	// reported older.
+	loadedAt time.Time
+}
+
+func newHTMLTransform(cfg configuration.AppConfig, htmlVarMap map[string]string, loadedAt time.Time) (*htmlTransform, error) {
+	t := &htmlTransform{
+		replacer: newHTMLReplacer(htmlVarMap),
+		loadedAt: loadedAt,
+	}
+
+	if cfg.Server.InjectPublicConfig {
+		var err error
+		t.publicConfigScript, err = newPublicConfigScript(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
 }
 
 func NewFSList(
@@ -41,51 +116,148 @@ func NewFSList(
 	cfg configuration.AppConfig,
 	htmlVarMap map[string]string,
 	fss ...FSItem,
-) FSList {
+) (FSList, error) {
 	if cfg.Server.StaticFileServerImmutable && !devMode {
 		for i := range fss {
 			fss[i].immutable = true
 		}
 	}
 
-	return FSList{
-		devMode:      devMode,
-		cfg:          cfg,
-		filesystems:  fss,
-		cache:        make(map[string]FSCacheItem),
-		htmlVarMap:   htmlVarMap,
-		htmlReplacer: newHTMLReplacer(htmlVarMap),
+	cachePolicy, err := newCachePolicy(devMode, cfg.Server.StaticCache)
+	if err != nil {
+		return FSList{}, fmt.Errorf("failed to create static cache policy: %w", err)
+	}
+
+	var proxy *viteProxy
+	for _, fsItem := range fss {
+		if p, ok := fsItem.fs.(*viteProxy); ok {
+			proxy = p
+			break
+		}
+	}
+
+	f := FSList{
+		createdAt:   time.Now(),
+		devMode:     devMode,
+		cfg:         cfg,
+		basePath:    configuration.NormalizeBasePath(cfg.Server.BasePath),
+		filesystems: fss,
+		cache:       newFSCache(cfg.Server.FileCache),
+		html:        &atomic.Pointer[htmlTransform]{},
+		cachePolicy: cachePolicy,
+		liveReload:  devMode && cfg.Server.LiveReload,
+		viteProxy:   proxy,
+
+		fallbackRoutes:  normalizePathPrefixes(cfg.Server.IndexFallbackRoutes),
+		fallbackExclude: normalizePathPrefixes(cfg.Server.IndexFallbackExclude),
+		notFoundPage:    strings.TrimPrefix(path.Clean("/"+cfg.Server.NotFoundPage), "/"),
+		maintenancePage: strings.TrimPrefix(path.Clean("/"+cfg.Server.Maintenance.Page), "/"),
+
+		meta: &MetaRegistry{},
+	}
+
+	f.security, err = newSecurityHeaders(cfg.Server.Security)
+	if err != nil {
+		return FSList{}, fmt.Errorf("failed to create security headers: %w", err)
+	}
+
+	if cfg.Server.Security.SubresourceIntegrity {
+		f.integrityDigests = newIntegrityDigests()
+	}
+
+	html, err := newHTMLTransform(cfg, htmlVarMap, f.createdAt)
+	if err != nil {
+		return FSList{}, err
+	}
+	f.html.Store(html)
+
+	// the Vite dev server emits no manifest and the embedded one may be stale
+	if cfg.Server.IndexFallback && cfg.Server.RouteManifest != "" && proxy == nil {
+		f.routes, err = f.loadRouteManifest(strings.TrimPrefix(path.Clean("/"+cfg.Server.RouteManifest), "/"))
+		if err != nil {
+			return FSList{}, err
+		}
+	}
+
+	f.redirects, err = f.loadRedirects(cfg.Server)
+	if err != nil {
+		return FSList{}, err
 	}
+
+	return f, nil
 }
 
-func (f *FSList) cached(name string) (FSCacheItem, bool) {
-	f.cacheMutex.RLock()
-	defer f.cacheMutex.RUnlock()
+// mountPath returns the absolute path of route below the base path.
+func (f *FSList) mountPath(route string) string {
+	return f.basePath[:len(f.basePath)-1] + route
+}
 
-	cachedFs, ok := f.cache[name]
-	return cachedFs, ok
+func (f *FSList) cached(name string) (FSCacheItem, bool) {
+	return f.cache.get(name)
 }
 
 func (f *FSList) cacheFS(name string, item FSCacheItem) {
-	f.cacheMutex.Lock()
-	defer f.cacheMutex.Unlock()
+	f.cache.set(name, item)
+}
 
-	f.cache[name] = item
+// updateCached applies update to the cached item of name, if there is one.
+func (f *FSList) updateCached(name string, update func(item *FSCacheItem)) {
+	f.cache.update(name, update)
+}
+
+// CacheStats returns the hit, miss and eviction counters of the file cache
+// together with its current and maximum size.
+func (f *FSList) CacheStats() FSCacheStats {
+	return f.cache.stats()
+}
+
+// invalidate drops all cached files and digests, e.g. after a static layer
+// has been swapped.
+func (f *FSList) invalidate() {
+	f.cache.invalidate("")
+	if f.integrityDigests != nil {
+		f.integrityDigests.reset()
+	}
+}
+
+// reloadHTML transforms the HTML pages with the values of a reloaded config
+// from now on. Whether the public config is injected is kept from startup.
+func (f *FSList) reloadHTML(cfg configuration.AppConfig, htmlVarMap map[string]string) error {
+	cfg.Server.InjectPublicConfig = f.cfg.Server.InjectPublicConfig
+
+	html, err := newHTMLTransform(cfg, htmlVarMap, time.Now())
+	if err != nil {
+		return err
+	}
+
+	f.html.Store(html)
+	f.invalidate()
+	return nil
 }
 
 func (f *FSList) Open(name string) (fs.File, error) {
-	item, ok := f.cached(name)
-	if ok {
-		if item.err == nil {
-			if item.content != nil {
-				return newMemFile(item.content, item.info), nil
-			}
-			return item.fs.Open(name)
-		}
-		if f.cfg.Server.IndexFallback && name != router.IndexPage {
+	item, file, err := f.resolve(name)
+	if err != nil {
+		if f.indexFallback(name, "") {
 			return f.Open(router.IndexPage)
 		}
-		return nil, item.err
+		return nil, err
+	}
+
+	if file != nil {
+		return file, nil
+	}
+
+	return item.open(name)
+}
+
+// resolve looks up name in the cache or, on a miss, in the filesystems in order.
+// When the lookup had to open a file that is served as is, the open file is
+// returned alongside the item so it does not have to be opened twice.
+func (f *FSList) resolve(name string) (FSCacheItem, fs.File, error) {
+	item, ok := f.cached(name)
+	if ok {
+		return item, nil, item.err
 	}
 
 	lastErr := os.ErrNotExist
@@ -97,46 +269,81 @@ func (f *FSList) Open(name string) (fs.File, error) {
 			continue
 		}
 
-		if f.shouldApplyHTMLVars(name) {
+		if f.shouldTransformHTML(name) {
 			content, info, transformErr := f.transformHTMLFile(name, file)
 			if transformErr != nil {
 				lastErr = transformErr
 				continue
 			}
 
-			returnContent := content
+			item := FSCacheItem{
+				content:   content,
+				info:      info,
+				immutable: fsItem.immutable,
+				etag:      contentETag(content),
+			}
 			if fsItem.immutable {
-				f.cacheFS(name, FSCacheItem{content: content, info: info, err: nil})
+				f.cacheFS(name, item)
 			}
 
-			return newMemFile(returnContent, info), nil
+			return item, nil, nil
 		}
 
+		item := FSCacheItem{fs: fsItem.fs, immutable: fsItem.immutable}
 		if fsItem.immutable {
-			f.cacheFS(name, FSCacheItem{fs: fsItem.fs, err: nil})
+			f.cacheFS(name, item)
 		}
 
-		return file, nil
+		return item, file, nil
 	}
 
 	if f.cfg.Server.StaticFileServerImmutable && !f.devMode {
-		f.cacheFS(name, FSCacheItem{err: lastErr})
+		f.cacheFS(name, FSCacheItem{err: lastErr, immutable: true})
 	}
 
-	if f.cfg.Server.IndexFallback && name != router.IndexPage {
-		return f.Open(router.IndexPage)
+	return FSCacheItem{err: lastErr}, nil, lastErr
+}
+
+// readStaticFile reads name from the FSList without the SPA index fallback.
+func (f *FSList) readStaticFile(name string) ([]byte, error) {
+	item, file, err := f.resolve(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open '%s': %w", name, err)
 	}
 
-	return nil, lastErr
+	if file == nil {
+		file, err = item.open(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open '%s': %w", name, err)
+		}
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", name, err)
+	}
+	return data, nil
 }
 
-func (f *FSList) shouldApplyHTMLVars(name string) bool {
-	if f.htmlReplacer == nil {
-		return false
+func (item FSCacheItem) open(name string) (fs.File, error) {
+	if item.content != nil {
+		return newMemFile(item.content, item.info), nil
 	}
+	return item.fs.Open(name)
+}
+
+// shouldTransformHTML reports whether name goes through transformHTMLFile,
+// which every HTML file does, as `%APP_BASE_PATH%` is always replaced.
+func (f *FSList) shouldTransformHTML(name string) bool {
 	return filepath.Ext(name) == ".html"
 }
 
+// transformHTMLFile replaces the HTML variables and `%APP_BASE_PATH%` in
+// file, adds Subresource
+// Integrity attributes, moves its URLs below the base path, injects the public
+// config and, in dev mode, the live reload client. The result is never
+// reported older than the values it was transformed with.
 func (f *FSList) transformHTMLFile(name string, file fs.File) ([]byte, fs.FileInfo, error) {
 	defer file.Close()
 
@@ -150,28 +357,46 @@ func (f *FSList) transformHTMLFile(name string, file fs.File) ([]byte, fs.FileIn
 		return nil, nil, err
 	}
 
-	if bytes.IndexByte(data, '%') != -1 {
-		transformed := f.htmlReplacer.Replace(string(data))
+	html := f.html.Load()
+	if html.replacer != nil && bytes.IndexByte(data, '%') != -1 {
+		transformed := html.replacer.Replace(string(data))
 		data = []byte(transformed)
 	}
 
+	// independent of the HTML variables, the base path is part of the layout
+	data = bytes.ReplaceAll(data, []byte(basePathVar), []byte(htmlEscapers["html"](f.basePath)))
+
+	if f.integrityDigests != nil {
+		data = f.addIntegrity(name, data)
+	}
+
+	if f.basePath != "/" {
+		data = rebaseHTML(data, f.basePath)
+	}
+
+	if html.publicConfigScript != nil {
+		data = injectBeforeClosingTag(data, "</head>", html.publicConfigScript)
+	}
+
+	if f.liveReload {
+		data = injectLiveReloadScript(data, f.mountPath(liveReloadPath))
+	}
+
 	info := cloneFileInfo(name, stat, int64(len(data)))
+	if info.modTime.Before(html.loadedAt) {
+		info.modTime = html.loadedAt
+	}
 	return data, info, nil
 }
 
+// newHTMLReplacer replaces the HTML variables with their escaped values, see
+// htmlEscapers for the available escaping modes.
 func newHTMLReplacer(vars map[string]string) *strings.Replacer {
 	if len(vars) == 0 {
 		return nil
 	}
 
-	pairs := make([]string, 0, len(vars)*2)
-	for key, val := range vars {
-		if key == "" {
-			continue
-		}
-		pairs = append(pairs, key, val)
-	}
-
+	pairs := escapedHTMLVars(vars)
 	if len(pairs) == 0 {
 		return nil
 	}
@@ -239,13 +464,12 @@ func (m *memFileInfo) Sys() any {
 	return nil
 }
 
-func cloneFileInfo(name string, src fs.FileInfo, size int64) fs.FileInfo {
+func cloneFileInfo(name string, src fs.FileInfo, size int64) *memFileInfo {
 	info := &memFileInfo{
-		name:    filepath.Base(name),
-		size:    size,
-		modTime: time.Now(),
-		mode:    0,
-		isDir:   false,
+		name:  filepath.Base(name),
+		size:  size,
+		mode:  0,
+		isDir: false,
 	}
 
 	if src != nil {
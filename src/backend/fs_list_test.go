@@ -1,11 +1,16 @@
 package backend
 
 import (
+	"compress/gzip"
 	"errors"
 	"io"
 	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/pocketbase/pocketbase/tools/router"
 	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
@@ -31,7 +36,10 @@ func TestFSListHTMLReplacementAndImmutableCache(t *testing.T) {
 		"index.html": &fstest.MapFile{Data: []byte("<title>%APP_CONFIG_GENERAL_NAME%</title>")},
 	}
 
-	fsList := NewFSList(false, cfg, htmlVars, FSItem{fs: &countingFS{fs: baseFS, opens: &counter}})
+	fsList, err := NewFSList(false, cfg, htmlVars, FSItem{fs: &countingFS{fs: baseFS, opens: &counter}})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
 	file1, err := fsList.Open("index.html")
 	if err != nil {
 		t.Fatalf("unexpected error on first open: %v", err)
@@ -87,7 +95,10 @@ func TestFSListDevModeSkipsImmutableCache(t *testing.T) {
 		"index.html": &fstest.MapFile{Data: []byte("<title>%APP_CONFIG_GENERAL_NAME%</title>")},
 	}
 
-	fsList := NewFSList(true, cfg, htmlVars, FSItem{fs: &countingFS{fs: baseFS, opens: &counter}})
+	fsList, err := NewFSList(true, cfg, htmlVars, FSItem{fs: &countingFS{fs: baseFS, opens: &counter}})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
 	file1, err := fsList.Open("index.html")
 	if err != nil {
 		t.Fatalf("unexpected error on first open: %v", err)
@@ -130,9 +141,12 @@ func TestFSListDevModeSkipsImmutableCache(t *testing.T) {
 func TestFSListCachesErrorsWhenImmutable(t *testing.T) {
 	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
 	counter := 0
-	fsList := NewFSList(false, cfg, nil, FSItem{fs: &countingFS{fs: fstest.MapFS{}, opens: &counter}})
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: &countingFS{fs: fstest.MapFS{}, opens: &counter}})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
 
-	_, err := fsList.Open("missing.txt")
+	_, err = fsList.Open("missing.txt")
 	if !errors.Is(err, fs.ErrNotExist) {
 		t.Fatalf("expected not exist error, got %v", err)
 	}
@@ -159,7 +173,10 @@ func TestFSListIndexFallbackReturnsIndex(t *testing.T) {
 		router.IndexPage: &fstest.MapFile{Data: []byte("<body>%APP_CONFIG_GENERAL_DESCRIPTION%</body>")},
 	}
 
-	fsList := NewFSList(false, cfg, htmlVars, FSItem{fs: &countingFS{fs: baseFS, opens: &counter}})
+	fsList, err := NewFSList(false, cfg, htmlVars, FSItem{fs: &countingFS{fs: baseFS, opens: &counter}})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
 	file, err := fsList.Open("missing.html")
 	if err != nil {
 		t.Fatalf("unexpected error when falling back to index: %v", err)
@@ -193,3 +210,231 @@ func TestFSListIndexFallbackReturnsIndex(t *testing.T) {
 		t.Fatalf("expected cached fallback to reuse cached data, got %d", counter)
 	}
 }
+
+func TestNegotiateEncoding(t *testing.T) {
+	both := map[string]encodedVariant{"br": {}, "gzip": {}}
+	gzipOnly := map[string]encodedVariant{"gzip": {}}
+
+	tests := []struct {
+		name      string
+		header    string
+		available map[string]encodedVariant
+		expected  string
+	}{
+		{name: "no header", header: "", available: both, expected: ""},
+		{name: "no variants", header: "gzip, br", available: nil, expected: ""},
+		{name: "server preference", header: "gzip, deflate, br", available: both, expected: "br"},
+		{name: "quality wins", header: "br;q=0.5, gzip", available: both, expected: "gzip"},
+		{name: "refused coding", header: "br;q=0, gzip;q=0", available: both, expected: ""},
+		{name: "only gzip available", header: "br, gzip", available: gzipOnly, expected: "gzip"},
+		{name: "wildcard", header: "*", available: both, expected: "br"},
+		{name: "x-gzip alias", header: "x-gzip", available: gzipOnly, expected: "gzip"},
+		{name: "unsupported only", header: "deflate, zstd", available: both, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := negotiateEncoding(tt.header, tt.available)
+			if result != tt.expected {
+				t.Errorf("negotiateEncoding(%q) = %q, expected %q", tt.header, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFSListServePrefersPrecompressedSibling(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
+	baseFS := fstest.MapFS{
+		"assets/index.js":    &fstest.MapFile{Data: []byte("console.log('hello')")},
+		"assets/index.js.br": &fstest.MapFile{Data: []byte("brotli")},
+	}
+
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	rec := serveRange(t, &fsList, "assets/index.js", http.Header{"Accept-Encoding": {"gzip, br"}})
+	if got := rec.Header().Get("Content-Encoding"); got != "br" || rec.Body.String() != "brotli" {
+		t.Fatalf("expected brotli sibling, got encoding %q with %q", got, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/javascript; charset=utf-8" {
+		t.Fatalf("expected the content type of the original name, got %q", got)
+	}
+
+	rec = serveRange(t, &fsList, "assets/index.js", http.Header{"Accept-Encoding": {"identity"}})
+	if got := rec.Header().Get("Content-Encoding"); got != "" || rec.Body.String() != "console.log('hello')" {
+		t.Fatalf("expected identity representation, got encoding %q with %q", got, rec.Body.String())
+	}
+}
+
+func TestFSListServeCompressesTransformedHTML(t *testing.T) {
+	htmlVars := map[string]string{"%APP_CONFIG_GENERAL_NAME%": "MyApp"}
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
+	page := "<title>%APP_CONFIG_GENERAL_NAME%</title>" + strings.Repeat("<p>padding</p>", 64)
+	baseFS := fstest.MapFS{
+		"index.html":    &fstest.MapFile{Data: []byte(page)},
+		"index.html.br": &fstest.MapFile{Data: []byte("stale brotli with placeholders")},
+	}
+
+	fsList, err := NewFSList(false, cfg, htmlVars, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	req := newStaticRequest(http.MethodGet, "/", "")
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	rec := httptest.NewRecorder()
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected transformed HTML to be gzip encoded, got %q", got)
+	}
+
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("open gzip reader: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read gzip content: %v", err)
+	}
+
+	if !strings.HasPrefix(string(data), "<title>MyApp</title>") {
+		t.Fatalf("expected replaced variables in compressed HTML, got %q", data[:32])
+	}
+}
+
+func TestFSListTransformKeepsStableModTime(t *testing.T) {
+	htmlVars := map[string]string{"%APP_CONFIG_GENERAL_NAME%": "MyApp"}
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<title>%APP_CONFIG_GENERAL_NAME%</title>")},
+	}
+
+	fsList, err := NewFSList(true, configuration.AppConfig{}, htmlVars, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	modTimes := make([]time.Time, 0, 2)
+	for range 2 {
+		file, err := fsList.Open("index.html")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		info, err := file.Stat()
+		file.Close()
+		if err != nil {
+			t.Fatalf("stat transformed file: %v", err)
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+
+	if !modTimes[0].Equal(modTimes[1]) || !modTimes[0].Equal(fsList.createdAt) {
+		t.Fatalf("expected transformed files to report the FSList creation time, got %v", modTimes)
+	}
+}
+
+func TestHTMLReplacerEscapesValues(t *testing.T) {
+	replacer := newHTMLReplacer(map[string]string{"%APP_CONFIG_GENERAL_DESCRIPTION%": `a "b" <c> & d's`})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`%APP_CONFIG_GENERAL_DESCRIPTION%`, `a &#34;b&#34; &lt;c&gt; &amp; d&#39;s`},
+		{`%APP_CONFIG_GENERAL_DESCRIPTION|html%`, `a &#34;b&#34; &lt;c&gt; &amp; d&#39;s`},
+		{`%APP_CONFIG_GENERAL_DESCRIPTION|attr%`, `a&#x20;&#x22;b&#x22;&#x20;&#x3C;c&#x3E;&#x20;&#x26;&#x20;d&#x27;s`},
+		{`%APP_CONFIG_GENERAL_DESCRIPTION|js%`, `a \"b\" \u003Cc\u003E \u0026 d\'s`},
+		{`%APP_CONFIG_GENERAL_DESCRIPTION|url%`, `a+%22b%22+%3Cc%3E+%26+d%27s`},
+		{`%APP_CONFIG_GENERAL_DESCRIPTION|raw%`, `a "b" <c> & d's`},
+		{`%APP_CONFIG_GENERAL_DESCRIPTION|unknown%`, `%APP_CONFIG_GENERAL_DESCRIPTION|unknown%`},
+	}
+
+	for _, tt := range tests {
+		if got := replacer.Replace(tt.input); got != tt.expected {
+			t.Errorf("Replace(%s) = %s, want %s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestFSListInjectsPublicConfig(t *testing.T) {
+	cfg := configuration.AppConfig{
+		General: configuration.GeneralConfig{Name: "</script>"},
+		Server:  configuration.ServerConfig{InjectPublicConfig: true},
+	}
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<head><title>App</title></head><body></body>")},
+	}
+
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	file, err := fsList.Open("index.html")
+	if err != nil {
+		t.Fatalf("unexpected error opening index: %v", err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+
+	expected := `<head><title>App</title><script>window.__APP_CONFIG__={"general":{"description":"","name":"\u003c/script\u003e","url":"","version":""}};</script></head><body></body>`
+	if string(data) != expected {
+		t.Fatalf("unexpected content:\n%s\nexpected:\n%s", data, expected)
+	}
+}
+
+func TestFSListReloadHTML(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<head><title>%APP_CONFIG_GENERAL_NAME%</title></head>")},
+	}
+
+	fsList, err := NewFSList(false, cfg, map[string]string{"%APP_CONFIG_GENERAL_NAME%": "MyApp"}, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	read := func() (string, time.Time) {
+		file, err := fsList.Open("index.html")
+		if err != nil {
+			t.Fatalf("unexpected error opening index: %v", err)
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatalf("read index: %v", err)
+		}
+		info, err := file.Stat()
+		if err != nil {
+			t.Fatalf("stat index: %v", err)
+		}
+		return string(data), info.ModTime()
+	}
+
+	if data, _ := read(); data != "<head><title>MyApp</title></head>" {
+		t.Fatalf("unexpected content before the reload: %q", data)
+	}
+
+	// the public config is only injected if it was enabled at startup
+	reloaded := cfg
+	reloaded.Server.InjectPublicConfig = true
+	reloadedAt := time.Now()
+	if err := fsList.reloadHTML(reloaded, map[string]string{"%APP_CONFIG_GENERAL_NAME%": "Reloaded"}); err != nil {
+		t.Fatalf("unexpected error reloading: %v", err)
+	}
+
+	data, modTime := read()
+	if data != "<head><title>Reloaded</title></head>" {
+		t.Fatalf("expected the cached page to use the reloaded variables, got %q", data)
+	}
+	if modTime.Before(reloadedAt.Truncate(time.Second)) {
+		t.Fatalf("expected the reloaded page to be newer than the reload, got %v", modTime)
+	}
+}
@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// htmlEscapers are the escaping modes of HTML variables, selected with a
+// suffix like "%APP_CONFIG_GENERAL_NAME|attr%". A variable without suffix is
+// escaped with "html", which is safe in text and in quoted attributes.
+var htmlEscapers = map[string]func(string) string{
+	// html escapes text content and quoted attribute values.
+	"html": html.EscapeString,
+	// attr escapes every non-alphanumeric character, so the value is also
+	// safe in unquoted attributes.
+	"attr": escapeHTMLAttribute,
+	// js escapes the value for a JavaScript string literal in a script element.
+	"js": template.JSEscapeString,
+	// url escapes the value as a URL query component.
+	"url": url.QueryEscape,
+	// raw inserts the value as it is, for trusted markup only.
+	"raw": func(value string) string { return value },
+}
+
+func escapeHTMLAttribute(value string) string {
+	var sb strings.Builder
+	sb.Grow(len(value))
+
+	for _, r := range value {
+		if r >= 0x80 || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			sb.WriteRune(r)
+			continue
+		}
+		fmt.Fprintf(&sb, "&#x%02X;", r)
+	}
+
+	return sb.String()
+}
+
+// escapedHTMLVars expands each "%KEY%" variable into its escaped default and
+// one "%KEY|mode%" variable per escaping mode.
+func escapedHTMLVars(vars map[string]string) []string {
+	pairs := make([]string, 0, len(vars)*2*(len(htmlEscapers)+1))
+	for key, val := range vars {
+		if key == "" {
+			continue
+		}
+		pairs = append(pairs, key, html.EscapeString(val))
+
+		name, ok := strings.CutSuffix(key, "%")
+		if !ok || !strings.HasPrefix(name, "%") {
+			continue
+		}
+		for mode, escape := range htmlEscapers {
+			pairs = append(pairs, name+"|"+mode+"%", escape(val))
+		}
+	}
+	return pairs
+}
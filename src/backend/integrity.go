@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"html"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// integrityDigests memoizes the Subresource Integrity values of the assets
+// served from immutable filesystems, which never change while running.
+type integrityDigests struct {
+	mutex   sync.Mutex
+	digests map[string]string
+}
+
+func newIntegrityDigests() *integrityDigests {
+	return &integrityDigests{digests: make(map[string]string)}
+}
+
+func (d *integrityDigests) reset() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	clear(d.digests)
+}
+
+var (
+	integrityScriptPattern = regexp.MustCompile(`(?is)<script\b[^>]*>`)
+	integrityLinkPattern   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+
+	srcAttribute         = htmlAttributePattern("src")
+	hrefAttribute        = htmlAttributePattern("href")
+	relAttribute         = htmlAttributePattern("rel")
+	integrityAttribute   = htmlAttributePattern("integrity")
+	crossoriginAttribute = htmlAttributePattern("crossorigin")
+)
+
+// htmlAttributePattern matches the attribute name of a tag, either with a
+// double quoted, single quoted or unquoted value or without any.
+func htmlAttributePattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\s` + name + `(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))|[\s/>])`)
+}
+
+// htmlAttribute returns the unescaped value of the attribute matched by
+// pattern and whether tag has that attribute at all.
+func htmlAttribute(tag []byte, pattern *regexp.Regexp) (string, bool) {
+	match := pattern.FindSubmatch(tag)
+	if match == nil {
+		return "", false
+	}
+
+	for _, value := range match[1:] {
+		if value != nil {
+			return html.UnescapeString(string(value)), true
+		}
+	}
+	return "", true
+}
+
+// addIntegrity adds integrity and crossorigin attributes to the script and
+// stylesheet elements of the HTML page name whose asset is served from an
+// immutable filesystem. Other elements are left alone, as their content may
+// change without the page being transformed again.
+func (f *FSList) addIntegrity(name string, doc []byte) []byte {
+	rewrite := func(attr *regexp.Regexp) func(tag []byte) []byte {
+		return func(tag []byte) []byte {
+			if _, ok := htmlAttribute(tag, integrityAttribute); ok {
+				return tag
+			}
+
+			if attr == hrefAttribute {
+				rel, _ := htmlAttribute(tag, relAttribute)
+				if !strings.Contains(strings.ToLower(rel), "stylesheet") {
+					return tag
+				}
+			}
+
+			ref, ok := htmlAttribute(tag, attr)
+			if !ok {
+				return tag
+			}
+
+			integrity := f.integrity(path.Dir(name), ref)
+			if integrity == "" {
+				return tag
+			}
+
+			attributes := ` integrity="` + integrity + `"`
+			if _, ok := htmlAttribute(tag, crossoriginAttribute); !ok {
+				attributes += ` crossorigin="anonymous"`
+			}
+
+			// insert right behind the element name, "<script" or "<link"
+			index := bytes.IndexAny(tag[1:], " \t\r\n\f/>") + 1
+			result := make([]byte, 0, len(tag)+len(attributes))
+			result = append(result, tag[:index]...)
+			result = append(result, attributes...)
+			result = append(result, tag[index:]...)
+			return result
+		}
+	}
+
+	doc = integrityScriptPattern.ReplaceAllFunc(doc, rewrite(srcAttribute))
+	return integrityLinkPattern.ReplaceAllFunc(doc, rewrite(hrefAttribute))
+}
+
+// integrity returns the Subresource Integrity value of the asset ref links to
+// from a page in dir, or an empty string if it is not a local asset served
+// from an immutable filesystem.
+func (f *FSList) integrity(dir string, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return ""
+	}
+
+	name := u.Path
+	if !strings.HasPrefix(name, "/") {
+		name = path.Join("/", dir, name)
+	}
+	name = strings.TrimPrefix(path.Clean(name), "/")
+
+	f.integrityDigests.mutex.Lock()
+	digest, ok := f.integrityDigests.digests[name]
+	f.integrityDigests.mutex.Unlock()
+	if ok {
+		return digest
+	}
+
+	item, file, err := f.resolve(name)
+	if err != nil || !item.immutable {
+		if file != nil {
+			file.Close()
+		}
+		return ""
+	}
+
+	if file == nil {
+		file, err = item.open(name)
+		if err != nil {
+			return ""
+		}
+	}
+	defer file.Close()
+
+	hash := sha512.New384()
+	if _, err := io.Copy(hash, file); err != nil {
+		return ""
+	}
+	digest = "sha384-" + base64.StdEncoding.EncodeToString(hash.Sum(nil))
+
+	f.integrityDigests.mutex.Lock()
+	f.integrityDigests.digests[name] = digest
+	f.integrityDigests.mutex.Unlock()
+
+	return digest
+}
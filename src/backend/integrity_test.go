@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func sha384Integrity(data string) string {
+	digest := sha512.Sum384([]byte(data))
+	return "sha384-" + base64.StdEncoding.EncodeToString(digest[:])
+}
+
+func TestHTMLAttribute(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected string
+		found    bool
+	}{
+		{tag: `<script src="/app.js">`, expected: "/app.js", found: true},
+		{tag: `<script type=module src='/a&amp;b.js'>`, expected: "/a&b.js", found: true},
+		{tag: `<script SRC=/app.js defer>`, expected: "/app.js", found: true},
+		{tag: `<script data-src="/app.js">`, found: false},
+		{tag: `<script>`, found: false},
+	}
+
+	for _, test := range tests {
+		got, found := htmlAttribute([]byte(test.tag), srcAttribute)
+		if got != test.expected || found != test.found {
+			t.Errorf("%s: expected (%q, %v), got (%q, %v)", test.tag, test.expected, test.found, got, found)
+		}
+	}
+
+	if _, found := htmlAttribute([]byte(`<script crossorigin src="/app.js">`), crossoriginAttribute); !found {
+		t.Error("expected an attribute without a value to be found")
+	}
+}
+
+func TestTransformAddsIntegrity(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		StaticFileServerImmutable: true,
+		Security:                  configuration.SecurityConfig{SubresourceIntegrity: true},
+	}}
+
+	page := `<html><head>` +
+		`<link rel="stylesheet" crossorigin href="/assets/app.css">` +
+		`<link rel="icon" href="/assets/app.css">` +
+		`<script type="module" src="../assets/app.js"></script>` +
+		`<script src="/live.js"></script>` +
+		`<script src="https://cdn.example.com/lib.js"></script>` +
+		`<script src="/assets/app.js" integrity="sha384-pinned"></script>` +
+		`</head></html>`
+
+	embedded := fstest.MapFS{
+		"docs/index.html": &fstest.MapFile{Data: []byte(page)},
+		"assets/app.css":  &fstest.MapFile{Data: []byte("body{}")},
+		"assets/app.js":   &fstest.MapFile{Data: []byte("console.log(1)")},
+		"live.js":         &fstest.MapFile{Data: []byte("embedded")},
+	}
+	public := fstest.MapFS{
+		"live.js": &fstest.MapFile{Data: []byte("public")},
+	}
+
+	fsList, err := NewFSList(true, cfg, nil, FSItem{fs: public}, FSItem{fs: embedded, immutable: true})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	got := readAll(t, &fsList, "docs/index.html")
+
+	for _, expected := range []string{
+		`<link integrity="` + sha384Integrity("body{}") + `" rel="stylesheet" crossorigin href="/assets/app.css">`,
+		`<link rel="icon" href="/assets/app.css">`,
+		`<script integrity="` + sha384Integrity("console.log(1)") + `" crossorigin="anonymous" type="module" src="../assets/app.js">`,
+		`<script src="/live.js">`,
+		`<script src="https://cdn.example.com/lib.js">`,
+		`<script src="/assets/app.js" integrity="sha384-pinned">`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected %q in %q", expected, got)
+		}
+	}
+}
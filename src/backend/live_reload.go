@@ -0,0 +1,119 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// liveReloadPath is the SSE endpoint the injected client script connects to.
+const liveReloadPath = "/api/dev/live-reload"
+
+// liveReloadScript returns the client that reloads the page whenever the
+// server at endpoint reports changed files.
+func liveReloadScript(endpoint string) []byte {
+	return []byte(`<script>new EventSource("` + endpoint + `").addEventListener("reload",()=>location.reload())</script>`)
+}
+
+// injectLiveReloadScript inserts the live reload client before the closing
+// body tag, or appends it if there is none.
+func injectLiveReloadScript(data []byte, endpoint string) []byte {
+	return injectBeforeClosingTag(data, "</body>", liveReloadScript(endpoint))
+}
+
+// injectBeforeClosingTag inserts fragment before the last occurrence of the
+// closing tag, matched case-insensitively, or appends it if there is none.
+func injectBeforeClosingTag(data []byte, tag string, fragment []byte) []byte {
+	index := bytes.LastIndex(bytes.ToLower(data), []byte(tag))
+	if index == -1 {
+		index = len(data)
+	}
+
+	result := make([]byte, 0, len(data)+len(fragment))
+	result = append(result, data[:index]...)
+	result = append(result, fragment...)
+	result = append(result, data[index:]...)
+	return result
+}
+
+// liveReload pushes reload events to all browsers connected over SSE.
+type liveReload struct {
+	mutex   sync.Mutex
+	clients map[chan []string]struct{}
+}
+
+func newLiveReload() *liveReload {
+	return &liveReload{clients: make(map[chan []string]struct{})}
+}
+
+// notify sends the changed names to every connected client. A client that
+// still has an undelivered event is skipped, it is going to reload anyway.
+func (l *liveReload) notify(names []string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for client := range l.clients {
+		select {
+		case client <- names:
+		default:
+		}
+	}
+}
+
+func (l *liveReload) subscribe() chan []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	client := make(chan []string, 1)
+	l.clients[client] = struct{}{}
+	return client
+}
+
+func (l *liveReload) unsubscribe(client chan []string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.clients, client)
+}
+
+func (l *liveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	controller := http.NewResponseController(w)
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	client := l.subscribe()
+	defer l.unsubscribe(client)
+
+	fmt.Fprint(w, "retry: 1000\n\n")
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(25 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case names := <-client:
+			data, err := json.Marshal(names)
+			if err != nil {
+				data = []byte("[]")
+			}
+			fmt.Fprintf(w, "event: reload\ndata: %s\n\n", data)
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
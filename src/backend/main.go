@@ -2,9 +2,14 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
-	"os"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/apis"
@@ -33,6 +38,15 @@ func newPocketBase(
 		Dir: "pb_data/../src/backend/migrations",
 	})
 
+	if isDev {
+		written, err := configuration.WritePublicTypeScript(cfg, publicConfigDeclaration)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		} else if written {
+			log.Printf("Updated the public config declaration '%s'.\n", publicConfigDeclaration)
+		}
+	}
+
 	var htmlVarMap map[string]string
 	var err error
 
@@ -43,28 +57,202 @@ func newPocketBase(
 		}
 	}
 
-	var fsList FSList
+	var appItems []FSItem
 	if isDev {
-		fsList = NewFSList(
-			isDev,
-			cfg,
-			htmlVarMap,
-			FSItem{fs: os.DirFS("dist")},
-			FSItem{fs: os.DirFS("pb_public")},
+		distItem := newDirFSItem("dist")
+		if cfg.Server.ViteDevServerURL != "" {
+			proxy, err := newViteProxy(cfg.Server.ViteDevServerURL, cfg.Server.BasePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create Vite dev server proxy: %w", err)
+			}
+			distItem = FSItem{fs: proxy}
+		}
+
+		appItems = []FSItem{
+			distItem,
+			newDirFSItem("pb_public"),
 			FSItem{fs: dist, immutable: true},
-		)
+		}
 	} else {
-		fsList = NewFSList(
-			isDev,
-			cfg,
-			htmlVarMap,
+		appItems = []FSItem{
 			FSItem{fs: dist, immutable: true},
-			FSItem{fs: os.DirFS("pb_public")},
-		)
+			newDirFSItem("pb_public"),
+		}
+	}
+
+	staticLayers, err := newStaticLayers(cfg.Server.StaticLayers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create static layers: %w", err)
+	}
+	appItems = staticLayers.stack(appItems)
+
+	fsList, err := NewFSList(isDev, cfg, htmlVarMap, slices.Clone(appItems)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create static file system list: %w", err)
 	}
 
+	virtualHosts, err := newVirtualHosts(isDev, cfg, htmlVarMap, &fsList, appItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create virtual hosts: %w", err)
+	}
+
+	cors := newReloadableCORS(cfg.Server.AllowedOrigins)
+
+	// applies the reloadable settings, the others are reported by reloadConfig
+	configuration.Subscribe(func(_ configuration.AppConfig, current configuration.AppConfig) {
+		cors.set(current.Server.AllowedOrigins)
+
+		var htmlVarMap map[string]string
+		if cfg.Server.ReplaceHTMLVars {
+			var err error
+			htmlVarMap, err = configuration.HTMLMap()
+			if err != nil {
+				log.Printf("Failed to reload the HTML variables: %v\n", err)
+				return
+			}
+		}
+		if err := fsList.reloadHTML(current, htmlVarMap); err != nil {
+			log.Printf("Failed to reload the HTML pages: %v\n", err)
+		}
+		if err := virtualHosts.reloadHTML(current, htmlVarMap); err != nil {
+			log.Printf("Failed to reload the HTML pages: %v\n", err)
+		}
+
+		if current.General.Debug {
+			if err := configuration.DebugPrint(nil, isDev); err != nil {
+				log.Printf("Warning: failed to print debug info: %v", err)
+			}
+		}
+	})
+
+	maintenance, err := newMaintenance(cfg, virtualHosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create maintenance mode: %w", err)
+	}
+
+	if !isDev && cfg.Server.PreloadStatic {
+		stats, err := fsList.Preload(dist)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Preloaded %d static files in %s, the file cache holds %d KiB.\n", stats.Files, stats.Duration, stats.Cache.Bytes/1024)
+
+		configuration.SetDebugSection("Static Preload", func(w io.Writer) {
+			fmt.Fprintf(w, "  Files:         %d\n", stats.Files)
+			fmt.Fprintf(w, "  Duration:      %s\n", stats.Duration)
+			fmt.Fprintf(w, "  Cache Entries: %d of %d\n", stats.Cache.Entries, stats.Cache.MaxEntries)
+			fmt.Fprintf(w, "  Cache Memory:  %d of %d bytes\n", stats.Cache.Bytes, stats.Cache.MaxBytes)
+			fmt.Fprintf(w, "  Evictions:     %d\n", stats.Cache.Evictions)
+		})
+	}
+
+	registerPageMeta(app, cfg, fsList.Meta())
+
+	liveReloadEnabled := isDev && cfg.Server.LiveReload
+	reloader := newLiveReload()
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+
+	staticLayers.watch(watchCtx, func(path string) {
+		fsList.invalidate()
+		virtualHosts.invalidate()
+		log.Printf("Reloaded the static layer '%s'.\n", path)
+	})
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		stopWatching()
+		return e.Next()
+	})
+
 	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
-		se.Router.GET("/{path...}", apis.Static(&fsList, cfg.Server.IndexFallback))
+		cors.bind(se)
+		watchConfigReload(watchCtx, cfg.Server.WatchConfig)
+
+		se.Router.BindFunc(fsList.SecurityHeaders())
+
+		if err := maintenance.restore(se.App.DB()); err != nil {
+			return err
+		}
+		se.Router.BindFunc(maintenance.Middleware())
+
+		if liveReloadEnabled {
+			fsList.Watch(watchCtx, reloader.notify)
+
+			// Handler: GET /api/dev/live-reload
+			// Purpose: Server-sent events stream that tells the dev pages to reload after dist or pb_public changed.
+			// Responses:
+			//   200: text/event-stream with a "reload" event per change, data is a JSON array of the changed names.
+			se.Router.GET(configuration.MountPath(cfg, liveReloadPath), apis.WrapStdHandler(reloader))
+		}
+
+		se.Router.GET(configuration.MountPath(cfg, "/{path...}"), virtualHosts.Static())
+
+		if basePath := configuration.NormalizeBasePath(cfg.Server.BasePath); basePath != "/" {
+			// Handler: GET /<base path without its trailing slash>
+			// Purpose: Redirects to the base path, where the SPA is served.
+			// Responses:
+			//   301: Redirect to the base path with its trailing slash.
+			se.Router.GET(strings.TrimSuffix(basePath, "/"), func(e *core.RequestEvent) error {
+				return e.Redirect(http.StatusMovedPermanently, basePath)
+			})
+		}
+
+		// Handler: GET /api/static/cache-stats
+		// Purpose: Reports the size and the hit, miss and eviction counters of the static file cache.
+		// Responses:
+		//   200: FSCacheStats as JSON.
+		//   401/403: If the request is not authenticated as a superuser.
+		se.Router.GET(configuration.MountPath(cfg, "/api/static/cache-stats"), func(e *core.RequestEvent) error {
+			return e.JSON(http.StatusOK, fsList.CacheStats())
+		}).Bind(apis.RequireSuperuserAuth())
+
+		// Handler: GET /api/maintenance
+		// Purpose: Reports whether the maintenance mode is on.
+		// Responses:
+		//   200: MaintenanceState as JSON.
+		//   401/403: If the request is not authenticated as a superuser.
+		se.Router.GET(configuration.MountPath(cfg, "/api/maintenance"), func(e *core.RequestEvent) error {
+			return e.JSON(http.StatusOK, maintenance.State())
+		}).Bind(apis.RequireSuperuserAuth())
+
+		// Handler: PUT /api/maintenance
+		// Purpose: Toggles the maintenance mode, the state is kept across restarts.
+		// Request Body: {"enabled": boolean}
+		// Responses:
+		//   200: The new MaintenanceState as JSON.
+		//   400: If the body is invalid.
+		//   401/403: If the request is not authenticated as a superuser.
+		//   409: If disabling a maintenance mode enabled by the configuration.
+		se.Router.PUT(configuration.MountPath(cfg, "/api/maintenance"), func(e *core.RequestEvent) error {
+			var body struct {
+				Enabled *bool `json:"enabled"`
+			}
+			if err := e.BindBody(&body); err != nil || body.Enabled == nil {
+				return e.Error(
+					http.StatusBadRequest,
+					"Invalid request body. Please send a JSON object with an 'enabled' boolean.",
+					err,
+				)
+			}
+
+			err := maintenance.SetEnabled(*body.Enabled)
+			if errors.Is(err, errMaintenanceConfigured) {
+				return e.Error(
+					http.StatusConflict,
+					"The maintenance mode is enabled by the configuration and cannot be disabled at runtime.",
+					nil,
+				)
+			}
+			if err != nil {
+				return e.Error(
+					http.StatusInternalServerError,
+					fmt.Sprintf("Failed to toggle the maintenance mode: %v.", err),
+					err,
+				)
+			}
+
+			log.Printf("Maintenance mode set to %v.\n", *body.Enabled)
+			return e.JSON(http.StatusOK, maintenance.State())
+		}).Bind(apis.RequireSuperuserAuth())
 
 		return se.Next()
 	})
@@ -108,7 +296,13 @@ func Main(
 		return fmt.Errorf("failed to create PocketBase instance: %w", err)
 	}
 
+	// printed once the app is set up, so sections like the static preload are included
+	if _, err := configuration.DebugPrintIfEnabled(nil, isDev); err != nil {
+		log.Printf("Warning: failed to print debug info: %v", err)
+	}
+
 	api.RegisterUserAPI(app, cfg)
+	api.RegisterConfigAPI(app, cfg)
 
 	return startAndWait(ctx, cancelCtx, app)
 }
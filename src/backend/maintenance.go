@@ -0,0 +1,244 @@
+package backend
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+const (
+	// maintenanceParamKey is the key of the toggled state in the PocketBase
+	// params table, next to the PocketBase settings.
+	maintenanceParamKey = "app_maintenance"
+
+	maintenanceBypassCookie = "app_maintenance_bypass"
+	maintenanceBypassParam  = "maintenance_bypass"
+)
+
+// errMaintenanceConfigured is returned when disabling a maintenance mode
+// enabled by the configuration.
+var errMaintenanceConfigured = errors.New("the maintenance mode is enabled by the configuration")
+
+// Maintenance takes the app offline for everyone but superusers, allowed IPs
+// and clients with the bypass cookie. Page requests get the maintenance page
+// and API requests an error, both with status 503.
+type Maintenance struct {
+	cfg      configuration.MaintenanceConfig
+	basePath string
+	allowed  []netip.Prefix
+	hosts    *VirtualHosts
+
+	// toggled is the state set by superusers, the configuration may enable
+	// the maintenance mode regardless.
+	toggled atomic.Bool
+
+	// db persists the toggled state, it is nil until the state is restored.
+	db    dbx.Builder
+	mutex sync.Mutex
+}
+
+// MaintenanceState is the maintenance mode as reported by the API.
+type MaintenanceState struct {
+	Enabled bool `json:"enabled"`
+
+	// Configured reports that the configuration enables the maintenance
+	// mode, so it cannot be disabled at runtime.
+	Configured bool `json:"configured"`
+}
+
+func newMaintenance(cfg configuration.AppConfig, hosts *VirtualHosts) (*Maintenance, error) {
+	m := &Maintenance{
+		cfg:      cfg.Server.Maintenance,
+		basePath: configuration.NormalizeBasePath(cfg.Server.BasePath),
+		hosts:    hosts,
+	}
+
+	for _, allowed := range m.cfg.AllowedIPs {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(allowed)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(allowed)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid allowed IP '%s': %w", allowed, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		m.allowed = append(m.allowed, prefix.Masked())
+	}
+
+	return m, nil
+}
+
+// Active reports whether the maintenance mode is on.
+func (m *Maintenance) Active() bool {
+	return m.cfg.Enabled || m.toggled.Load()
+}
+
+// State returns the current maintenance mode.
+func (m *Maintenance) State() MaintenanceState {
+	return MaintenanceState{Enabled: m.Active(), Configured: m.cfg.Enabled}
+}
+
+// restore loads the toggled state from db and persists later changes there.
+func (m *Maintenance) restore(db dbx.Builder) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var value string
+	err := db.NewQuery("SELECT [[value]] FROM {{_params}} WHERE [[id]] = {:id} LIMIT 1").
+		Bind(dbx.Params{"id": maintenanceParamKey}).
+		Row(&value)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to load maintenance state: %w", err)
+	}
+
+	if err == nil {
+		var state MaintenanceState
+		if err := json.Unmarshal([]byte(value), &state); err != nil {
+			return fmt.Errorf("failed to parse maintenance state: %w", err)
+		}
+		m.toggled.Store(state.Enabled)
+	}
+
+	m.db = db
+	return nil
+}
+
+// SetEnabled toggles the maintenance mode and persists the new state.
+func (m *Maintenance) SetEnabled(enabled bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !enabled && m.cfg.Enabled {
+		return errMaintenanceConfigured
+	}
+
+	if m.db != nil {
+		value, err := json.Marshal(MaintenanceState{Enabled: enabled})
+		if err != nil {
+			return fmt.Errorf("failed to encode maintenance state: %w", err)
+		}
+
+		_, err = m.db.NewQuery(
+			"INSERT INTO {{_params}} ([[id]], [[value]]) VALUES ({:id}, {:value}) " +
+				"ON CONFLICT ([[id]]) DO UPDATE SET [[value]] = excluded.[[value]], [[updated]] = strftime('%Y-%m-%d %H:%M:%fZ')",
+		).Bind(dbx.Params{"id": maintenanceParamKey, "value": string(value)}).Execute()
+		if err != nil {
+			return fmt.Errorf("failed to save maintenance state: %w", err)
+		}
+	}
+
+	m.toggled.Store(enabled)
+	return nil
+}
+
+// Middleware answers the requests that are not let through during
+// maintenance. It has to run after the PocketBase auth middleware.
+func (m *Maintenance) Middleware() func(*core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		if done, err := m.serve(e.Response, e.Request, e.RealIP(), e.HasSuperuserAuth()); done {
+			return err
+		}
+		return e.Next()
+	}
+}
+
+// serve answers r if it is not let through during maintenance. done reports
+// whether the request has been answered.
+func (m *Maintenance) serve(w http.ResponseWriter, r *http.Request, ip string, superuser bool) (done bool, err error) {
+	if !m.Active() || m.bypassed(w, r, ip) {
+		return false, nil
+	}
+
+	urlPath := r.URL.Path
+	switch {
+	// superusers have to be able to sign in to the dashboard and end the maintenance
+	case strings.HasPrefix(urlPath, "/_/"),
+		strings.HasPrefix(urlPath, "/api/collections/"+core.CollectionNameSuperusers+"/"),
+		urlPath == "/api/health":
+		return false, nil
+	case strings.HasPrefix(urlPath, "/api/"), strings.HasPrefix(urlPath, m.basePath+"api/"):
+		if superuser {
+			return false, nil
+		}
+		m.setRetryAfter(w)
+		return true, router.NewApiError(http.StatusServiceUnavailable, "The app is down for maintenance.", nil)
+	}
+
+	// assets stay available, e.g. for the styles of the maintenance page
+	if ext := path.Ext(urlPath); ext != "" && !isHTMLFile(urlPath) {
+		return false, nil
+	}
+
+	m.setRetryAfter(w)
+	if list := m.hosts.list(r.Host); list != nil && list.maintenancePage != "" {
+		served, err := list.servePage(w, r, list.maintenancePage, r.Header.Get("Accept-Encoding"), http.StatusServiceUnavailable)
+		if served {
+			return true, err
+		}
+	}
+	return true, router.NewApiError(http.StatusServiceUnavailable, "The app is down for maintenance.", nil)
+}
+
+// bypassed reports whether r comes from an allowed IP or carries the bypass
+// cookie. A request with the bypass token as query parameter gets the cookie.
+func (m *Maintenance) bypassed(w http.ResponseWriter, r *http.Request, ip string) bool {
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		addr = addr.Unmap()
+		for _, prefix := range m.allowed {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+	}
+
+	if m.cfg.BypassToken == "" {
+		return false
+	}
+
+	if cookie, err := r.Cookie(maintenanceBypassCookie); err == nil && m.validBypassToken(cookie.Value) {
+		return true
+	}
+
+	if m.validBypassToken(r.URL.Query().Get(maintenanceBypassParam)) {
+		http.SetCookie(w, &http.Cookie{
+			Name:     maintenanceBypassCookie,
+			Value:    m.cfg.BypassToken,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return true
+	}
+
+	return false
+}
+
+func (m *Maintenance) validBypassToken(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(m.cfg.BypassToken)) == 1
+}
+
+func (m *Maintenance) setRetryAfter(w http.ResponseWriter) {
+	if m.cfg.RetryAfterSeconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(m.cfg.RetryAfterSeconds))
+	}
+}
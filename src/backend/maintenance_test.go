@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestMaintenanceServe(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		Maintenance: configuration.MaintenanceConfig{
+			Page:              "maintenance.html",
+			RetryAfterSeconds: 120,
+			AllowedIPs:        []string{"10.0.0.0/8", "2001:db8::1"},
+			BypassToken:       "s3cret",
+		},
+	}}
+	baseFS := fstest.MapFS{
+		"maintenance.html": &fstest.MapFile{Data: []byte("<h1>Back soon</h1>")},
+	}
+
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+	hosts, err := newVirtualHosts(false, cfg, nil, &fsList, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating virtual hosts: %v", err)
+	}
+	m, err := newMaintenance(cfg, hosts)
+	if err != nil {
+		t.Fatalf("unexpected error creating maintenance mode: %v", err)
+	}
+
+	serve := func(urlPath string, ip string, superuser bool) (*httptest.ResponseRecorder, bool, error) {
+		req := httptest.NewRequest(http.MethodGet, urlPath, nil)
+		rec := httptest.NewRecorder()
+		done, err := m.serve(rec, req, ip, superuser)
+		return rec, done, err
+	}
+
+	if _, done, _ := serve("/", "192.0.2.1", false); done {
+		t.Fatal("expected requests to pass while the maintenance mode is off")
+	}
+
+	if err := m.SetEnabled(true); err != nil {
+		t.Fatalf("unexpected error enabling the maintenance mode: %v", err)
+	}
+
+	rec, done, err := serve("/dashboard", "192.0.2.1", false)
+	if !done || err != nil {
+		t.Fatalf("expected the maintenance page, got done %v and error %v", done, err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "<h1>Back soon</h1>" {
+		t.Fatalf("expected the maintenance page with status 503, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Retry-After") != "120" {
+		t.Fatalf("expected Retry-After 120, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	_, done, err = serve("/api/collections/posts/records", "192.0.2.1", false)
+	var apiErr *router.ApiError
+	if !done || !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+		t.Fatalf("expected API requests to be rejected with status 503, got done %v and error %v", done, err)
+	}
+
+	for _, test := range []struct {
+		urlPath   string
+		ip        string
+		superuser bool
+	}{
+		{urlPath: "/api/collections/posts/records", ip: "192.0.2.1", superuser: true},
+		{urlPath: "/api/collections/_superusers/auth-with-password", ip: "192.0.2.1"},
+		{urlPath: "/_/", ip: "192.0.2.1"},
+		{urlPath: "/assets/app.css", ip: "192.0.2.1"},
+		{urlPath: "/dashboard", ip: "10.1.2.3"},
+		{urlPath: "/dashboard", ip: "2001:db8::1"},
+	} {
+		if _, done, _ := serve(test.urlPath, test.ip, test.superuser); done {
+			t.Errorf("%s from %s: expected the request to pass", test.urlPath, test.ip)
+		}
+	}
+
+	rec, done, _ = serve("/dashboard?maintenance_bypass=s3cret", "192.0.2.1", false)
+	cookies := rec.Result().Cookies()
+	if done || len(cookies) != 1 || cookies[0].Name != maintenanceBypassCookie {
+		t.Fatalf("expected the bypass token to pass and set the cookie, got done %v and cookies %v", done, cookies)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.AddCookie(cookies[0])
+	if done, _ := m.serve(httptest.NewRecorder(), req, "192.0.2.1", false); done {
+		t.Fatal("expected the bypass cookie to pass")
+	}
+
+	if _, done, _ := serve("/dashboard?maintenance_bypass=wrong", "192.0.2.1", false); !done {
+		t.Fatal("expected a wrong bypass token to be rejected")
+	}
+
+	if err := m.SetEnabled(false); err != nil || m.Active() {
+		t.Fatalf("expected the maintenance mode to be disabled, got error %v", err)
+	}
+
+	m.cfg.Enabled = true
+	if err := m.SetEnabled(false); !errors.Is(err, errMaintenanceConfigured) {
+		t.Fatalf("expected an error disabling a configured maintenance mode, got %v", err)
+	}
+}
@@ -0,0 +1,313 @@
+package backend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PageMeta describes the head tags of a single page. Empty fields are left
+// as they are in the HTML file, set fields replace the existing tags.
+type PageMeta struct {
+	Title       string
+	Description string
+	Canonical   string
+	Image       string
+
+	// OpenGraph holds further OpenGraph properties keyed without the "og:"
+	// prefix, e.g. "type" or "image:alt".
+	OpenGraph map[string]string
+}
+
+// MetaHandler returns the PageMeta of the request path it was registered
+// for. Wildcard values are available through r.PathValue. A nil PageMeta
+// serves the page unchanged.
+type MetaHandler func(r *http.Request) (*PageMeta, error)
+
+type metaRoute struct {
+	pattern  string
+	segments []string
+	handler  MetaHandler
+}
+
+// MetaRegistry maps SPA routes to MetaHandlers, so link previews and crawlers
+// see page specific titles and OpenGraph tags. The HTML file itself is still
+// cached as a whole, the tags are spliced into its head on every request.
+type MetaRegistry struct {
+	mutex  sync.RWMutex
+	routes []metaRoute
+}
+
+// Handle registers handler for the routes matching pattern. Patterns are
+// slash separated paths where a "{name}" segment matches any single segment
+// and a final "{name...}" segment matches the remainder of the path. The
+// first registered pattern that matches wins.
+//
+//	meta.Handle("/tags/{tag}", func(r *http.Request) (*PageMeta, error) {
+//		return &PageMeta{Title: "Posts tagged " + r.PathValue("tag")}, nil
+//	})
+//
+// Routes showing PocketBase records use recordPageMeta, which keeps to the
+// view rule of their collection.
+func (m *MetaRegistry) Handle(pattern string, handler MetaHandler) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.routes = append(m.routes, metaRoute{
+		pattern:  pattern,
+		segments: splitRoutePath(pattern),
+		handler:  handler,
+	})
+}
+
+// lookup calls the handler of the first route matching urlPath.
+func (m *MetaRegistry) lookup(r *http.Request, urlPath string) *PageMeta {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	segments := splitRoutePath(urlPath)
+	for _, route := range m.routes {
+		values, ok := matchMetaRoute(route.segments, segments)
+		if !ok {
+			continue
+		}
+
+		req := r.Clone(r.Context())
+		for name, value := range values {
+			req.SetPathValue(name, value)
+		}
+
+		meta, err := route.handler(req)
+		if err != nil {
+			log.Printf("Failed to build the page meta of '%s' for route '%s': %v\n", urlPath, route.pattern, err)
+			return nil
+		}
+		return meta
+	}
+
+	return nil
+}
+
+func matchMetaRoute(pattern []string, segments []string) (map[string]string, bool) {
+	values := make(map[string]string)
+
+	for i, segment := range pattern {
+		name, isWildcard := strings.CutPrefix(segment, "{")
+		if isWildcard {
+			name, isWildcard = strings.CutSuffix(name, "}")
+		}
+		if !isWildcard {
+			if i >= len(segments) || segment != segments[i] {
+				return nil, false
+			}
+			continue
+		}
+
+		if rest, ok := strings.CutSuffix(name, "..."); ok {
+			values[rest] = strings.Join(segments[min(i, len(segments)):], "/")
+			return values, true
+		}
+
+		if i >= len(segments) {
+			return nil, false
+		}
+		values[name] = segments[i]
+	}
+
+	return values, len(pattern) == len(segments)
+}
+
+var (
+	headTitlePattern     = regexp.MustCompile(`(?is)<title\b[^>]*>.*?</title\s*>\s*`)
+	headCanonicalPattern = regexp.MustCompile(`(?is)<link\b[^>]*\brel\s*=\s*["']?canonical\b[^>]*>\s*`)
+	headClosePattern     = regexp.MustCompile(`(?i)</head\s*>`)
+)
+
+// headMetaPattern matches the meta tags whose attr (name or property) is key.
+func headMetaPattern(attr string, key string) *regexp.Regexp {
+	return regexp.MustCompile(`(?is)<meta\b[^>]*\b` + attr + `\s*=\s*["']?` + regexp.QuoteMeta(key) + `["'\s/>][^>]*>\s*`)
+}
+
+// spliceHead replaces the tags of doc's head that meta overrides and inserts
+// the new ones right before the closing head tag. The body is left alone, it
+// may contain e.g. SVG title elements.
+func spliceHead(doc []byte, meta *PageMeta) []byte {
+	index := len(doc)
+	if loc := headClosePattern.FindIndex(doc); loc != nil {
+		index = loc[0]
+	}
+
+	head := bytes.Clone(doc[:index])
+	var tags bytes.Buffer
+
+	writeMeta := func(attr string, key string, value string) {
+		head = headMetaPattern(attr, key).ReplaceAll(head, nil)
+		fmt.Fprintf(&tags, "<meta %s=\"%s\" content=\"%s\" />", attr, html.EscapeString(key), html.EscapeString(value))
+	}
+
+	if meta.Title != "" {
+		head = headTitlePattern.ReplaceAll(head, nil)
+		fmt.Fprintf(&tags, "<title>%s</title>", html.EscapeString(meta.Title))
+		writeMeta("property", "og:title", meta.Title)
+	}
+	if meta.Description != "" {
+		writeMeta("name", "description", meta.Description)
+		writeMeta("property", "og:description", meta.Description)
+	}
+	if meta.Canonical != "" {
+		head = headCanonicalPattern.ReplaceAll(head, nil)
+		fmt.Fprintf(&tags, "<link rel=\"canonical\" href=\"%s\" />", html.EscapeString(meta.Canonical))
+		writeMeta("property", "og:url", meta.Canonical)
+	}
+	if meta.Image != "" {
+		writeMeta("property", "og:image", meta.Image)
+	}
+
+	keys := make([]string, 0, len(meta.OpenGraph))
+	for key := range meta.OpenGraph {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		writeMeta("property", "og:"+key, meta.OpenGraph[key])
+	}
+
+	result := make([]byte, 0, len(head)+tags.Len()+len(doc)-index)
+	result = append(result, head...)
+	result = append(result, tags.Bytes()...)
+	result = append(result, doc[index:]...)
+	return result
+}
+
+// Meta returns the registry of per-route head tags of the served HTML pages.
+func (f *FSList) Meta() *MetaRegistry {
+	return f.meta
+}
+
+// serveDynamicHTML writes the HTML page rep with the head tags of meta, if
+// any, and a fresh CSP nonce if those are enabled. The cached page is spliced
+// and compressed per request. Its weak ETag is derived from the ETag of the
+// page and meta, so clients can still revalidate it.
+func (f *FSList) serveDynamicHTML(w http.ResponseWriter, r *http.Request, rep representation, meta *PageMeta, status int) error {
+	plain, err := f.openRepresentation(rep.name, "")
+	if err != nil {
+		return err
+	}
+	defer plain.file.Close()
+
+	doc, err := io.ReadAll(plain.file)
+	if err != nil {
+		return err
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), dynamicHTMLEncodings)
+	if status == http.StatusOK {
+		etag := f.dynamicHTMLETag(plain, doc, meta, encoding)
+		header.Set("ETag", etag)
+		header.Set("Cache-Control", f.cachePolicy.cacheControl(rep.name, rep.item.immutable))
+
+		// Without a new policy the client keeps the one stored with the page,
+		// which matches the nonces of its copy.
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && etagWeakMatch(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+
+	if meta != nil {
+		doc = spliceHead(doc, meta)
+	}
+	if f.security.nonces() {
+		nonce, err := newNonce()
+		if err != nil {
+			return err
+		}
+		doc = addNonces(doc, nonce)
+		f.security.setContentSecurityPolicy(header, nonce)
+	}
+
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	if encoding == "" {
+		header.Set("Content-Length", strconv.Itoa(len(doc)))
+	} else {
+		header.Set("Content-Encoding", encoding)
+	}
+
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	if encoding == "" {
+		_, err = w.Write(doc)
+		return err
+	}
+
+	writer := gzip.NewWriter(w)
+	if _, err := writer.Write(doc); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// dynamicHTMLEncodings are the content codings serveDynamicHTML compresses
+// pages with.
+var dynamicHTMLEncodings = map[string]encodedVariant{"gzip": {}}
+
+// dynamicHTMLETag returns the weak entity tag of the page plain with the
+// contents doc, spliced with meta and encoded by encoding. The nonces are
+// left out, they are not part of the page the client revalidates.
+func (f *FSList) dynamicHTMLETag(plain representation, doc []byte, meta *PageMeta, encoding string) string {
+	etag := f.entityTag(plain)
+	if etag == "" {
+		etag = contentETag(doc)
+	}
+
+	if meta != nil {
+		// fmt prints the OpenGraph map sorted by key
+		sum := sha256.Sum256(fmt.Appendf(nil, "%s%+v", etag, *meta))
+		etag = hashETag(sum[:])
+	}
+	if encoding != "" {
+		etag = strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+	}
+	return "W/" + etag
+}
+
+// dynamicHTML reports whether the page rep has to be served through
+// serveDynamicHTML even without page meta.
+func (f *FSList) dynamicHTML(rep representation) bool {
+	return f.security.nonces() && isHTMLFile(rep.name)
+}
+
+// pageMeta returns the PageMeta for serving the HTML page rep to r, if any.
+func (f *FSList) pageMeta(r *http.Request, requested string, rep representation) *PageMeta {
+	if f.meta == nil || !isHTMLFile(rep.name) {
+		return nil
+	}
+	// the site root, matched by the "/" pattern
+	if requested == "." {
+		requested = ""
+	}
+	return f.meta.lookup(r, requested)
+}
+
+func isHTMLFile(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".html")
+}
@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// registerPageMeta registers the head tags of the frontend routes. Routes
+// showing records look them up through app, see recordPageMeta.
+func registerPageMeta(app core.App, cfg configuration.AppConfig, meta *MetaRegistry) {
+	live := configuration.Live(cfg)
+
+	meta.Handle("/", func(r *http.Request) (*PageMeta, error) {
+		cfg := live.Load()
+		page := &PageMeta{
+			OpenGraph: map[string]string{
+				"type":      "website",
+				"site_name": cfg.General.Name,
+			},
+		}
+		if cfg.General.URL != "" {
+			page.Canonical = strings.TrimRight(cfg.General.URL, "/") + "/"
+		}
+		return page, nil
+	})
+
+	// previews the posts once a "posts" collection exists
+	meta.Handle("/posts/{id}", recordPageMeta(app, "posts", "title", "description"))
+}
+
+// recordPageMeta returns a MetaHandler for routes with an "{id}" segment that
+// titles the page by the titleField and describes it by the descriptionField
+// of the record of collection with that id. Link previews are requested
+// without authentication, so only records guests may view are shown. Missing
+// records or collections serve the page unchanged.
+func recordPageMeta(app core.App, collection string, titleField string, descriptionField string) MetaHandler {
+	return func(r *http.Request) (*PageMeta, error) {
+		if _, err := app.FindCachedCollectionByNameOrId(collection); err != nil {
+			return nil, nil
+		}
+
+		record, err := app.FindRecordById(collection, r.PathValue("id"))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		guest := &core.RequestInfo{
+			Context: core.RequestInfoContextDefault,
+			Method:  r.Method,
+			Query:   map[string]string{},
+			Headers: map[string]string{},
+			Body:    map[string]any{},
+		}
+		canView, err := app.CanAccessRecord(record, guest, record.Collection().ViewRule)
+		if err != nil || !canView {
+			return nil, err
+		}
+
+		return &PageMeta{
+			Title:       record.GetString(titleField),
+			Description: record.GetString(descriptionField),
+		}, nil
+	}
+}
@@ -0,0 +1,213 @@
+package backend
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestMatchMetaRoute(t *testing.T) {
+	tests := []struct {
+		pattern string
+		urlPath string
+		values  map[string]string
+		ok      bool
+	}{
+		{"/", "/", map[string]string{}, true},
+		{"/posts/{id}", "/posts/42", map[string]string{"id": "42"}, true},
+		{"/posts/{id}", "/posts", nil, false},
+		{"/posts/{id}", "/posts/42/comments", nil, false},
+		{"/docs/{rest...}", "/docs/a/b", map[string]string{"rest": "a/b"}, true},
+		{"/docs/{rest...}", "/docs", map[string]string{"rest": ""}, true},
+		{"/about", "/About", nil, false},
+	}
+
+	for _, tt := range tests {
+		values, ok := matchMetaRoute(splitRoutePath(tt.pattern), splitRoutePath(tt.urlPath))
+		if ok != tt.ok {
+			t.Fatalf("%s on %s: expected match %v, got %v", tt.pattern, tt.urlPath, tt.ok, ok)
+		}
+		if ok && len(values) != len(tt.values) {
+			t.Fatalf("%s on %s: expected %v, got %v", tt.pattern, tt.urlPath, tt.values, values)
+		}
+		for name, value := range tt.values {
+			if values[name] != value {
+				t.Fatalf("%s on %s: expected %v, got %v", tt.pattern, tt.urlPath, tt.values, values)
+			}
+		}
+	}
+}
+
+func TestSpliceHeadReplacesOverriddenTags(t *testing.T) {
+	doc := []byte(`<html><head><meta name="description" content="static" /><title>App</title></head>` +
+		`<body><svg><title>icon</title></svg></body></html>`)
+
+	got := string(spliceHead(doc, &PageMeta{
+		Title:       `Post "1" & more`,
+		Description: "A post",
+		OpenGraph:   map[string]string{"type": "article"},
+	}))
+
+	expected := `<html><head>` +
+		`<title>Post &#34;1&#34; &amp; more</title>` +
+		`<meta property="og:title" content="Post &#34;1&#34; &amp; more" />` +
+		`<meta name="description" content="A post" />` +
+		`<meta property="og:description" content="A post" />` +
+		`<meta property="og:type" content="article" />` +
+		`</head><body><svg><title>icon</title></svg></body></html>`
+
+	if got != expected {
+		t.Fatalf("unexpected document:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestStaticInjectsPageMeta(t *testing.T) {
+	htmlVars := map[string]string{"%APP_CONFIG_GENERAL_NAME%": "MyApp"}
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		StaticFileServerImmutable: true,
+		IndexFallback:             true,
+	}}
+	counter := 0
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<head><title>%APP_CONFIG_GENERAL_NAME%</title></head><body></body>")},
+	}
+	fsList, err := NewFSList(false, cfg, htmlVars, FSItem{fs: &countingFS{fs: baseFS, opens: &counter}})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	fsList.Meta().Handle("/posts/{id}", func(r *http.Request) (*PageMeta, error) {
+		return &PageMeta{Title: "Post " + r.PathValue("id")}, nil
+	})
+
+	etags := make(map[string]bool)
+	for _, id := range []string{"1", "2"} {
+		req := newStaticRequest(http.MethodGet, "/posts/"+id, "posts/"+id)
+		rec := httptest.NewRecorder()
+
+		if err := fsList.serve(rec, req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		body := rec.Body.String()
+		if !strings.Contains(body, "<title>Post "+id+"</title>") || strings.Contains(body, "MyApp") {
+			t.Fatalf("expected the title of post %s, got %q", id, body)
+		}
+
+		etag := rec.Header().Get("ETag")
+		if !strings.HasPrefix(etag, `W/"`) || etags[etag] {
+			t.Fatalf("expected a weak ETag per post, got %q", etag)
+		}
+		etags[etag] = true
+	}
+
+	req := newStaticRequest(http.MethodGet, "/other", "other")
+	rec := httptest.NewRecorder()
+
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(rec.Body.String(), "<title>MyApp</title>") {
+		t.Fatalf("expected the static title on other routes, got %q", rec.Body.String())
+	}
+
+	// The transformed index is cached once, only the head tags are per request.
+	if counter != 4 {
+		t.Fatalf("expected three misses and one index open, got %d", counter)
+	}
+}
+
+func TestStaticInjectsPageMetaAtTheRoot(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{IndexFallback: true}}
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<head><title>Static</title></head><body></body>")},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	fsList.Meta().Handle("/", func(r *http.Request) (*PageMeta, error) {
+		return &PageMeta{Title: "Home"}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	if err := fsList.serve(rec, newStaticRequest(http.MethodGet, "/", "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "<title>Home</title>") {
+		t.Fatalf("expected the title of the site root, got %q", body)
+	}
+}
+
+// recordsApp serves the records of a single collection, guests may view those
+// with a "public" field set.
+type recordsApp struct {
+	core.App
+	collection *core.Collection
+	records    map[string]*core.Record
+}
+
+func (a *recordsApp) FindCachedCollectionByNameOrId(nameOrId string) (*core.Collection, error) {
+	if a.collection == nil || nameOrId != a.collection.Name {
+		return nil, errors.New("missing collection")
+	}
+	return a.collection, nil
+}
+
+func (a *recordsApp) FindRecordById(collectionModelOrIdentifier any, recordId string, optFilters ...func(q *dbx.SelectQuery) error) (*core.Record, error) {
+	if record, ok := a.records[recordId]; ok {
+		return record, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (a *recordsApp) CanAccessRecord(record *core.Record, requestInfo *core.RequestInfo, accessRule *string) (bool, error) {
+	if requestInfo.Auth != nil {
+		return false, errors.New("expected a guest request")
+	}
+	public, _ := record.Get("public").(bool)
+	return public, nil
+}
+
+func TestRecordPageMeta(t *testing.T) {
+	collection := core.NewBaseCollection("posts")
+	app := &recordsApp{collection: collection, records: map[string]*core.Record{}}
+	for id, public := range map[string]bool{"public": true, "private": false} {
+		record := core.NewRecord(collection)
+		record.Set("title", "Post "+id)
+		record.Set("description", "About "+id)
+		record.Set("public", public)
+		app.records[id] = record
+	}
+
+	meta := &MetaRegistry{}
+	meta.Handle("/posts/{id}", recordPageMeta(app, "posts", "title", "description"))
+	lookup := func(urlPath string) *PageMeta {
+		return meta.lookup(httptest.NewRequest(http.MethodGet, urlPath, nil), urlPath)
+	}
+
+	page := lookup("/posts/public")
+	if page == nil || page.Title != "Post public" || page.Description != "About public" {
+		t.Fatalf("expected the meta of the public post, got %+v", page)
+	}
+	if page := lookup("/posts/private"); page != nil {
+		t.Fatalf("expected no meta for a post guests may not view, got %+v", page)
+	}
+	if page := lookup("/posts/missing"); page != nil {
+		t.Fatalf("expected no meta for a missing post, got %+v", page)
+	}
+
+	app.collection = nil
+	if page := lookup("/posts/public"); page != nil {
+		t.Fatalf("expected no meta without the collection, got %+v", page)
+	}
+}
@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// preloadAcceptEncoding accepts every supported content coding, so Preload
+// looks up all precompressed variants.
+const preloadAcceptEncoding = "br, gzip"
+
+// PreloadStats summarizes a Preload run.
+type PreloadStats struct {
+	Files    int
+	Duration time.Duration
+	Cache    FSCacheStats
+}
+
+// Preload walks fsys, usually the embedded frontend bundle, and fills the
+// cache with the files it serves, including transformed HTML, entity tags and
+// precompressed variants, so the first visitors after a deploy are served
+// from memory. Names are resolved through the whole FSList, so files shadowed
+// by a mutable filesystem are not cached.
+func (f *FSList) Preload(fsys fs.FS) (PreloadStats, error) {
+	start := time.Now()
+	stats := PreloadStats{}
+
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || isEncodedSibling(fsys, name) {
+			return nil
+		}
+
+		if err := f.preloadFile(name); err != nil {
+			return err
+		}
+		stats.Files++
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to preload static files: %w", err)
+	}
+
+	stats.Duration = time.Since(start)
+	stats.Cache = f.CacheStats()
+	return stats, nil
+}
+
+func (f *FSList) preloadFile(name string) error {
+	rep, err := f.openRepresentation(name, preloadAcceptEncoding)
+	if err != nil {
+		return fmt.Errorf("failed to open '%s': %w", name, err)
+	}
+	defer rep.file.Close()
+
+	f.entityTag(rep)
+	return nil
+}
+
+// isEncodedSibling reports whether name is the precompressed variant of
+// another file of fsys, which is loaded together with that file.
+func isEncodedSibling(fsys fs.FS, name string) bool {
+	ext := path.Ext(name)
+	for _, pref := range encodingPreference {
+		if ext != pref.extension {
+			continue
+		}
+
+		_, err := fs.Stat(fsys, strings.TrimSuffix(name, ext))
+		return err == nil
+	}
+	return false
+}
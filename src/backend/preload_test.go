@@ -0,0 +1,63 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestPreloadFillsCache(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		FileCache: configuration.FileCacheConfig{MaxEntries: 100, MaxMemoryMB: 1},
+	}}
+	embedded := fstest.MapFS{
+		"index.html":       &fstest.MapFile{Data: []byte("<html>%APP_CONFIG_NAME%</html>")},
+		"assets/app.js":    &fstest.MapFile{Data: []byte(strings.Repeat("console.log(1);", 20))},
+		"assets/app.js.br": &fstest.MapFile{Data: []byte("br")},
+		"backup.gz":        &fstest.MapFile{Data: []byte("archive")},
+		"shadowed.txt":     &fstest.MapFile{Data: []byte("embedded")},
+	}
+	public := fstest.MapFS{
+		"shadowed.txt": &fstest.MapFile{Data: []byte("public")},
+	}
+	htmlVarMap := map[string]string{"%APP_CONFIG_NAME%": "preloaded"}
+
+	fsList, err := NewFSList(false, cfg, htmlVarMap, FSItem{fs: public}, FSItem{fs: embedded, immutable: true})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	stats, err := fsList.Preload(embedded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stats.Files != 4 {
+		t.Fatalf("expected 4 preloaded files without the precompressed sibling, got %d", stats.Files)
+	}
+	if stats.Cache.Entries != 3 || stats.Cache.Bytes == 0 {
+		t.Fatalf("expected 3 cached entries using memory, got %+v", stats.Cache)
+	}
+
+	html, ok := fsList.cached("index.html")
+	if !ok || string(html.content) != "<html>preloaded</html>" || html.etag == "" {
+		t.Fatalf("expected the transformed HTML to be cached, got %+v", html)
+	}
+
+	script, ok := fsList.cached("assets/app.js")
+	if !ok || script.etag == "" {
+		t.Fatalf("expected the script with its entity tag to be cached, got %+v", script)
+	}
+	if _, ok := script.encoded["br"]; !ok {
+		t.Fatalf("expected the precompressed variants to be cached, got %+v", script.encoded)
+	}
+	if _, ok := script.encoded["gzip"]; !ok {
+		t.Fatalf("expected a gzip variant to be built, got %+v", script.encoded)
+	}
+
+	if _, ok := fsList.cached("shadowed.txt"); ok {
+		t.Fatal("expected a file shadowed by a mutable filesystem not to be cached")
+	}
+}
@@ -0,0 +1,28 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// publicConfigDeclaration is where the TypeScript declaration of
+// window.__APP_CONFIG__ is kept up to date in dev mode.
+const publicConfigDeclaration = "src/frontend/types/appConfig.d.ts"
+
+// newPublicConfigScript returns the script element setting
+// window.__APP_CONFIG__ to the public config values. json.Marshal escapes
+// "<", ">" and "&", so values cannot close the element.
+func newPublicConfigScript(cfg configuration.AppConfig) ([]byte, error) {
+	data, err := json.Marshal(configuration.PublicConfig(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public config: %w", err)
+	}
+
+	script := make([]byte, 0, len(data)+48)
+	script = append(script, "<script>window.__APP_CONFIG__="...)
+	script = append(script, data...)
+	script = append(script, ";</script>"...)
+	return script, nil
+}
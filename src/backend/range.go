@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// seekable returns the content of rep for http.ServeContent, which needs to
+// seek to answer Range and If-Range requests. Files of filesystems that cannot
+// seek are wrapped in a forwardSeeker, so partial content works no matter
+// which layer serves the file.
+func seekable(rep representation, size int64) io.ReadSeeker {
+	file := rep.file
+	if variant, ok := file.(*variantFile); ok {
+		file = variant.File
+	}
+
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		return seeker
+	}
+
+	return &forwardSeeker{
+		initial: file,
+		current: file,
+		size:    size,
+		reopen: func() (fs.File, error) {
+			// archives may have been replaced since the file was opened
+			if archived, ok := file.(*archiveFile); ok {
+				return archived.reopen()
+			}
+			if variant, ok := rep.file.(*variantFile); ok {
+				return rep.item.fs.Open(variant.name)
+			}
+			return rep.item.open(rep.name)
+		},
+	}
+}
+
+// forwardSeeker emulates seeking on a file that can only be read forward. A
+// seek just records the offset, the next read skips ahead to it or, to go
+// backwards, reopens the file and reads from the start again.
+type forwardSeeker struct {
+	// initial is the file handed to seekable, it is closed by its owner.
+	initial fs.File
+	current fs.File
+	reopen  func() (fs.File, error)
+
+	size     int64
+	offset   int64
+	position int64
+}
+
+func (s *forwardSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.offset
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return 0, errors.New("forwardSeeker.Seek: invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("forwardSeeker.Seek: negative position")
+	}
+
+	s.offset = offset
+	return offset, nil
+}
+
+func (s *forwardSeeker) Read(p []byte) (int, error) {
+	if s.offset < s.position {
+		file, err := s.reopen()
+		if err != nil {
+			return 0, err
+		}
+		s.Close()
+		s.current = file
+		s.position = 0
+	}
+
+	if s.offset > s.position {
+		skipped, err := io.CopyN(io.Discard, s.current, s.offset-s.position)
+		s.position += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := s.current.Read(p)
+	s.position += int64(n)
+	s.offset = s.position
+	return n, err
+}
+
+// Close closes the file opened by the last rewind, if any.
+func (s *forwardSeeker) Close() error {
+	if s.current == s.initial {
+		return nil
+	}
+	return s.current.Close()
+}
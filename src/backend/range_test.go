@@ -0,0 +1,191 @@
+package backend
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// streamFS hides the io.Seeker of its files, like archive filesystems do.
+type streamFS struct {
+	fs fstest.MapFS
+}
+
+type streamFile struct {
+	file fs.File
+}
+
+func (s *streamFS) Open(name string) (fs.File, error) {
+	file, err := s.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return streamFile{file: file}, nil
+}
+
+func (s streamFile) Stat() (fs.FileInfo, error) { return s.file.Stat() }
+func (s streamFile) Read(p []byte) (int, error) { return s.file.Read(p) }
+func (s streamFile) Close() error               { return s.file.Close() }
+
+func serveRange(t *testing.T, fsList *FSList, name string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := newStaticRequest(http.MethodGet, "/"+name, name)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	rec := httptest.NewRecorder()
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error serving %s: %v", name, err)
+	}
+	return rec
+}
+
+func TestStaticRangeRequestsOnEveryLayer(t *testing.T) {
+	const media = "0123456789abcdefghij"
+	const page = "<html>%APP_CONFIG_NAME%</html>"
+	const transformed = "<html>range test</html>"
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "video.mp4"), []byte(media), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	stream := &streamFS{fs: fstest.MapFS{
+		"archive.mp4":     &fstest.MapFile{Data: []byte(media)},
+		"archive.json":    &fstest.MapFile{Data: []byte("{}")},
+		"archive.json.br": &fstest.MapFile{Data: []byte(media)},
+	}}
+
+	tests := []struct {
+		name           string
+		file           string
+		acceptEncoding string
+		expected       string
+		item           FSItem
+	}{
+		{name: "mutable directory", file: "video.mp4", expected: media, item: newDirFSItem(dir)},
+		{name: "immutable filesystem", file: "clip.mp4", expected: media, item: FSItem{
+			fs:        fstest.MapFS{"clip.mp4": &fstest.MapFile{Data: []byte(media)}},
+			immutable: true,
+		}},
+		{name: "transformed HTML", file: "page.html", expected: transformed, item: FSItem{
+			fs:        fstest.MapFS{"page.html": &fstest.MapFile{Data: []byte(page)}},
+			immutable: true,
+		}},
+		{name: "precompressed sibling", file: "data.json", acceptEncoding: "br", expected: media, item: FSItem{
+			fs: fstest.MapFS{
+				"data.json":    &fstest.MapFile{Data: []byte("{}")},
+				"data.json.br": &fstest.MapFile{Data: []byte(media)},
+			},
+			immutable: true,
+		}},
+		{name: "non-seekable filesystem", file: "archive.mp4", expected: media, item: FSItem{fs: stream}},
+		{name: "non-seekable precompressed sibling", file: "archive.json", acceptEncoding: "br", expected: media, item: FSItem{fs: stream}},
+	}
+
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
+	htmlVarMap := map[string]string{"%APP_CONFIG_NAME%": "range test"}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fsList, err := NewFSList(false, cfg, htmlVarMap, test.item)
+			if err != nil {
+				t.Fatalf("unexpected error creating FSList: %v", err)
+			}
+
+			header := http.Header{}
+			if test.acceptEncoding != "" {
+				header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+
+			// served twice, so cached items are replayed as well
+			for range 2 {
+				full := serveRange(t, &fsList, test.file, header)
+				if full.Code != http.StatusOK || full.Body.String() != test.expected {
+					t.Fatalf("expected the full content, got %d %q", full.Code, full.Body.String())
+				}
+				if got := full.Header().Get("Accept-Ranges"); got != "bytes" {
+					t.Fatalf("expected Accept-Ranges bytes, got %q", got)
+				}
+
+				single := header.Clone()
+				single.Set("Range", "bytes=2-5")
+				rec := serveRange(t, &fsList, test.file, single)
+				if rec.Code != http.StatusPartialContent || rec.Body.String() != test.expected[2:6] {
+					t.Fatalf("expected partial content %q, got %d %q", test.expected[2:6], rec.Code, rec.Body.String())
+				}
+				if got, expected := rec.Header().Get("Content-Range"), "bytes 2-5/"+strconv.Itoa(len(test.expected)); got != expected {
+					t.Fatalf("expected Content-Range %q, got %q", expected, got)
+				}
+
+				// the second range lies before the first one, so non-seekable
+				// files have to be read from the start again
+				multi := header.Clone()
+				multi.Set("Range", "bytes=6-8,0-1")
+				rec = serveRange(t, &fsList, test.file, multi)
+				if rec.Code != http.StatusPartialContent {
+					t.Fatalf("expected status 206, got %d", rec.Code)
+				}
+				parts := readByteRanges(t, rec)
+				if len(parts) != 2 || parts[0] != test.expected[6:9] || parts[1] != test.expected[0:2] {
+					t.Fatalf("unexpected parts %q", parts)
+				}
+
+				validator := full.Header().Get("ETag")
+				if validator == "" {
+					validator = full.Header().Get("Last-Modified")
+				}
+				conditional := single.Clone()
+				conditional.Set("If-Range", validator)
+				if rec = serveRange(t, &fsList, test.file, conditional); rec.Code != http.StatusPartialContent {
+					t.Fatalf("expected status 206 for a current If-Range %q, got %d", validator, rec.Code)
+				}
+
+				conditional.Set("If-Range", `"stale"`)
+				rec = serveRange(t, &fsList, test.file, conditional)
+				if rec.Code != http.StatusOK || rec.Body.String() != test.expected {
+					t.Fatalf("expected the full content for a stale If-Range, got %d %q", rec.Code, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func readByteRanges(t *testing.T, rec *httptest.ResponseRecorder) []string {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("expected multipart/byteranges, got %q", rec.Header().Get("Content-Type"))
+	}
+
+	var parts []string
+	reader := multipart.NewReader(rec.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		parts = append(parts, string(data))
+	}
+}
@@ -0,0 +1,288 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// redirectSplat is the placeholder of the segments matched by a trailing "*".
+const redirectSplat = "splat"
+
+var (
+	redirectPlaceholderPattern = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+	redirectPlaceholderSegment = regexp.MustCompile(`^:[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// redirectRule is a parsed configuration.RedirectRule. Its pattern uses the
+// segments of the from path: ":name" matches one segment and a trailing "*"
+// all remaining ones.
+type redirectRule struct {
+	from    string
+	pattern []string
+	to      string
+	status  int
+
+	// source names the rule in validation errors, e.g. "_redirects:4".
+	source string
+}
+
+func newRedirectRule(from string, to string, status int, source string) (redirectRule, error) {
+	rule := redirectRule{
+		from:    from,
+		pattern: splitRoutePath(from),
+		to:      strings.TrimSpace(to),
+		status:  status,
+		source:  source,
+	}
+
+	if !strings.HasPrefix(from, "/") {
+		return redirectRule{}, fmt.Errorf("%s: the path '%s' does not start with a slash", source, from)
+	}
+	if rule.to == "" {
+		return redirectRule{}, fmt.Errorf("%s: the rule for '%s' has no target", source, from)
+	}
+
+	switch rule.status {
+	case 0:
+		rule.status = http.StatusMovedPermanently
+	case http.StatusOK, http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return redirectRule{}, fmt.Errorf("%s: unsupported status %d, expected 200, 301, 302, 307 or 308", source, status)
+	}
+
+	placeholders := map[string]bool{}
+	for i, segment := range rule.pattern {
+		switch {
+		case segment == "*":
+			if i != len(rule.pattern)-1 {
+				return redirectRule{}, fmt.Errorf("%s: '*' is only allowed as the last segment of '%s'", source, from)
+			}
+			placeholders[redirectSplat] = true
+		case segment[0] == ':':
+			if !redirectPlaceholderSegment.MatchString(segment) {
+				return redirectRule{}, fmt.Errorf("%s: invalid placeholder '%s' in '%s'", source, segment, from)
+			}
+			placeholders[segment[1:]] = true
+		}
+	}
+
+	for _, match := range redirectPlaceholderPattern.FindAllStringSubmatch(rule.to, -1) {
+		if !placeholders[match[1]] {
+			return redirectRule{}, fmt.Errorf("%s: the target '%s' uses the placeholder ':%s' missing in '%s'", source, rule.to, match[1], from)
+		}
+	}
+
+	if rule.status == http.StatusOK && (!strings.HasPrefix(rule.to, "/") || strings.HasPrefix(rule.to, "//")) {
+		return redirectRule{}, fmt.Errorf("%s: the rewrite target '%s' is not a local path", source, rule.to)
+	}
+
+	return rule, nil
+}
+
+// parseRedirectsFile parses a Netlify-style redirects file: one "from to
+// [status]" rule per line, blank lines and lines starting with "#" are ignored.
+func parseRedirectsFile(name string, data []byte) ([]redirectRule, error) {
+	var rules []redirectRule
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		source := fmt.Sprintf("%s:%d", name, i+1)
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("%s: expected 'from to [status]', got '%s'", source, line)
+		}
+
+		status := 0
+		if len(fields) == 3 {
+			var err error
+			status, err = strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid status '%s'", source, fields[2])
+			}
+		}
+
+		rule, err := newRedirectRule(fields[0], fields[1], status, source)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// loadRedirects parses the configured redirects followed by the rules of the
+// redirects file. A missing redirects file is not an error. The redirects
+// file itself is hidden from requests, see openRequested.
+func (f *FSList) loadRedirects(cfg configuration.ServerConfig) ([]redirectRule, error) {
+	rules := make([]redirectRule, 0, len(cfg.Redirects))
+	for i, redirect := range cfg.Redirects {
+		rule, err := newRedirectRule(redirect.From, redirect.To, redirect.Status, fmt.Sprintf("redirect rule %d", i+1))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if cfg.RedirectsFile != "" {
+		f.redirectsFile = strings.TrimPrefix(path.Clean("/"+cfg.RedirectsFile), "/")
+	}
+
+	// like the route manifest, the file is not fetched from the Vite dev server
+	if f.redirectsFile != "" && f.viteProxy == nil {
+		name := f.redirectsFile
+		data, err := f.readStaticFile(name)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to load redirects file: %w", err)
+		}
+
+		fileRules, err := parseRedirectsFile(name, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse redirects file: %w", err)
+		}
+		rules = append(rules, fileRules...)
+	}
+
+	if err := validateRedirects(rules); err != nil {
+		return nil, fmt.Errorf("invalid redirects: %w", err)
+	}
+
+	return rules, nil
+}
+
+// validateRedirects reports rules that can never match because an earlier
+// rule matches every path they match, and redirects to a path matching the
+// rule again, as they would loop forever.
+func validateRedirects(rules []redirectRule) error {
+	var errs []error
+
+	for i, rule := range rules {
+		for _, earlier := range rules[:i] {
+			if !coversRedirectPattern(earlier.pattern, rule.pattern) {
+				continue
+			}
+
+			if coversRedirectPattern(rule.pattern, earlier.pattern) {
+				errs = append(errs, fmt.Errorf("%s: '%s' conflicts with '%s' of %s", rule.source, rule.from, earlier.from, earlier.source))
+			} else {
+				errs = append(errs, fmt.Errorf("%s: '%s' is unreachable, '%s' of %s matches every path first", rule.source, rule.from, earlier.from, earlier.source))
+			}
+			break
+		}
+
+		if rule.status != http.StatusOK && !strings.Contains(rule.to, ":") {
+			target, _, _ := strings.Cut(rule.to, "?")
+			if _, ok := matchRedirectPattern(rule.pattern, splitRoutePath(target)); ok {
+				errs = append(errs, fmt.Errorf("%s: '%s' redirects to '%s', which it matches again", rule.source, rule.from, rule.to))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// coversRedirectPattern reports whether pattern a matches every path pattern b
+// matches.
+func coversRedirectPattern(a []string, b []string) bool {
+	for i, segment := range a {
+		if segment == "*" {
+			return true
+		}
+		if i >= len(b) || b[i] == "*" {
+			return false
+		}
+		if segment[0] == ':' {
+			continue
+		}
+		if b[i][0] == ':' || !strings.EqualFold(segment, b[i]) {
+			return false
+		}
+	}
+	return len(a) == len(b)
+}
+
+// matchRedirectPattern matches the path segments against pattern and returns
+// the values of its placeholders. Static segments are compared
+// case-insensitively, like the frontend router does.
+func matchRedirectPattern(pattern []string, segments []string) (map[string]string, bool) {
+	params := map[string]string{}
+	for i, segment := range pattern {
+		switch {
+		case segment == "*":
+			params[redirectSplat] = strings.Join(segments[i:], "/")
+			return params, true
+		case i >= len(segments):
+			return nil, false
+		case segment[0] == ':':
+			params[segment[1:]] = segments[i]
+		case !strings.EqualFold(segment, segments[i]):
+			return nil, false
+		}
+	}
+	return params, len(pattern) == len(segments)
+}
+
+// target replaces the placeholders of the rule target with params. Redirect
+// targets get the values escaped, as they end up in the Location header.
+func (rule redirectRule) target(params map[string]string) string {
+	return redirectPlaceholderPattern.ReplaceAllStringFunc(rule.to, func(placeholder string) string {
+		value := params[placeholder[1:]]
+		if rule.status == http.StatusOK {
+			return value
+		}
+
+		segments := strings.Split(value, "/")
+		for i := range segments {
+			segments[i] = url.PathEscape(segments[i])
+		}
+		return strings.Join(segments, "/")
+	})
+}
+
+// redirect applies the first redirect rule matching name. It answers
+// redirects itself and returns the name to serve instead for rewrites. done
+// reports whether the request has been answered.
+func (f *FSList) redirect(w http.ResponseWriter, r *http.Request, name string) (rewritten string, done bool) {
+	segments := splitRoutePath(name)
+	if name == "." {
+		segments = nil
+	}
+
+	for _, rule := range f.redirects {
+		params, ok := matchRedirectPattern(rule.pattern, segments)
+		if !ok {
+			continue
+		}
+
+		target := rule.target(params)
+		if rule.status == http.StatusOK {
+			target, _, _ = strings.Cut(target, "?")
+			return strings.TrimPrefix(path.Clean(target), "/"), false
+		}
+
+		if strings.HasPrefix(target, "/") {
+			target = safeRedirectPath(f.mountPath(target))
+		}
+		if r.URL.RawQuery != "" && !strings.Contains(target, "?") {
+			target += "?" + r.URL.RawQuery
+		}
+
+		http.Redirect(w, r, target, rule.status)
+		return name, true
+	}
+
+	return name, false
+}
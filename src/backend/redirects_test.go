@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestRedirectsServe(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		BasePath:      "/tools/",
+		RedirectsFile: "_redirects",
+		Redirects: []configuration.RedirectRule{
+			{From: "/blog/:year/:slug", To: "/news/:slug"},
+			{From: "/docs/*", To: "/handbook/:splat", Status: http.StatusFound},
+		},
+	}}
+	baseFS := fstest.MapFS{
+		"about.html": &fstest.MapFile{Data: []byte("about")},
+		"_redirects": &fstest.MapFile{Data: []byte("# moved pages\n/team  /about.html  200\n\n/old  https://example.com/new  308\n")},
+	}
+
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	tests := []struct {
+		urlPath  string
+		status   int
+		location string
+		body     string
+	}{
+		{urlPath: "/tools/blog/2024/hello%20world?ref=feed", status: http.StatusMovedPermanently, location: "/tools/news/hello%20world?ref=feed"},
+		{urlPath: "/tools/Docs/a/b", status: http.StatusFound, location: "/tools/handbook/a/b"},
+		{urlPath: "/tools/old", status: http.StatusPermanentRedirect, location: "https://example.com/new"},
+		{urlPath: "/tools/team", status: http.StatusOK, body: "about"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.urlPath, nil)
+		req.SetPathValue("path", strings.TrimPrefix(req.URL.Path, "/tools/"))
+		rec := httptest.NewRecorder()
+
+		if err := fsList.serve(rec, req); err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.urlPath, err)
+		}
+		if rec.Code != test.status {
+			t.Fatalf("%s: expected status %d, got %d", test.urlPath, test.status, rec.Code)
+		}
+		if location := rec.Header().Get("Location"); location != test.location {
+			t.Fatalf("%s: expected location %q, got %q", test.urlPath, test.location, location)
+		}
+		if test.body != "" && rec.Body.String() != test.body {
+			t.Fatalf("%s: expected body %q, got %q", test.urlPath, test.body, rec.Body.String())
+		}
+	}
+
+	// the redirects file configures the server and is not served
+	req := httptest.NewRequest(http.MethodGet, "/tools/_redirects", nil)
+	req.SetPathValue("path", "_redirects")
+	if err := fsList.serve(httptest.NewRecorder(), req); !errors.Is(err, router.ErrFileNotFound) {
+		t.Fatalf("expected router.ErrFileNotFound for the redirects file, got %v", err)
+	}
+}
+
+func TestRedirectsValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		redirects string
+		expected  string
+	}{
+		{name: "conflict", redirects: "/a/:x /b\n/a/:y /c", expected: "conflicts with '/a/:x'"},
+		{name: "unreachable", redirects: "/docs/* /handbook\n/docs/intro /start", expected: "'/docs/intro' is unreachable"},
+		{name: "loop", redirects: "/old/* /old", expected: "which it matches again"},
+		{name: "placeholder", redirects: "/a/:x /b/:y", expected: "placeholder ':y'"},
+		{name: "splat", redirects: "/a/*/b /c", expected: "only allowed as the last segment"},
+		{name: "status", redirects: "/a /b 404", expected: "unsupported status 404"},
+		{name: "rewrite", redirects: "/a https://example.com 200", expected: "not a local path"},
+	}
+
+	for _, test := range tests {
+		rules, err := parseRedirectsFile("_redirects", []byte(test.redirects))
+		if err == nil {
+			err = validateRedirects(rules)
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.name, test.expected, err)
+		}
+	}
+
+	rules, err := parseRedirectsFile("_redirects", []byte("/docs/intro /start\n/docs/* /handbook/:splat\n/ /home 302"))
+	if err != nil {
+		t.Fatalf("unexpected error parsing redirects: %v", err)
+	}
+	if err := validateRedirects(rules); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+}
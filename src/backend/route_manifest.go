@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// routeManifest lists the route patterns of the frontend router, as emitted
+// by the "route-manifest" plugin in vite.config.ts. Patterns use the syntax
+// of @solidjs/router: ":param" matches one segment, ":param?" an optional
+// one and "*" or "*rest" everything that follows.
+type routeManifest struct {
+	Routes []string `json:"routes"`
+
+	patterns [][]string
+}
+
+func parseRouteManifest(data []byte) (*routeManifest, error) {
+	manifest := &routeManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse route manifest: %w", err)
+	}
+
+	manifest.patterns = make([][]string, 0, len(manifest.Routes))
+	for _, route := range manifest.Routes {
+		manifest.patterns = append(manifest.patterns, splitRoutePath(route))
+	}
+
+	return manifest, nil
+}
+
+// loadRouteManifest reads the manifest name from the FSList. A missing
+// manifest is not an error, it just disables the route check.
+func (f *FSList) loadRouteManifest(name string) (*routeManifest, error) {
+	data, err := f.readStaticFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read route manifest: %w", err)
+	}
+
+	return parseRouteManifest(data)
+}
+
+func splitRoutePath(routePath string) []string {
+	segments := make([]string, 0, strings.Count(routePath, "/"))
+	for _, segment := range strings.Split(routePath, "/") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// match reports whether urlPath matches one of the routes.
+func (m *routeManifest) match(urlPath string) bool {
+	segments := splitRoutePath(urlPath)
+	for _, pattern := range m.patterns {
+		if matchRouteSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRouteSegments compares static segments case-insensitively, like the
+// frontend router does.
+func matchRouteSegments(pattern []string, segments []string) bool {
+	for i, segment := range pattern {
+		switch {
+		case segment[0] == '*':
+			return true
+		case segment[0] == ':' && strings.HasSuffix(segment, "?"):
+			if matchRouteSegments(pattern[i+1:], segments) {
+				return true
+			}
+			if len(segments) == 0 {
+				return false
+			}
+		case len(segments) == 0:
+			return false
+		case segment[0] != ':' && !strings.EqualFold(segment, segments[0]):
+			return false
+		}
+		segments = segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// knownRoute reports whether the frontend router has a route for name. Without
+// a manifest every route is known.
+func (f *FSList) knownRoute(name string) bool {
+	if f.routes == nil {
+		return true
+	}
+	return f.routes.match(name)
+}
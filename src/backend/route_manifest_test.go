@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestRouteManifestMatch(t *testing.T) {
+	manifest, err := parseRouteManifest([]byte(`{"routes": ["/", "/solid", "/users/:id", "/posts/:id?/edit", "/files/*rest"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error parsing manifest: %v", err)
+	}
+
+	tests := []struct {
+		urlPath string
+		want    bool
+	}{
+		{"/", true},
+		{"/solid", true},
+		{"/SOLID/", true},
+		{"/solid/extra", false},
+		{"/users/42", true},
+		{"/users", false},
+		{"/users/42/posts", false},
+		{"/posts/edit", true},
+		{"/posts/7/edit", true},
+		{"/posts/7/8/edit", false},
+		{"/files", true},
+		{"/files/a/b/c.txt", true},
+		{"/unknown", false},
+	}
+
+	for _, tt := range tests {
+		if got := manifest.match(tt.urlPath); got != tt.want {
+			t.Errorf("match(%q) = %v, want %v", tt.urlPath, got, tt.want)
+		}
+	}
+}
+
+func TestStaticUnknownRouteGetsNotFoundStatus(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		StaticFileServerImmutable: true,
+		IndexFallback:             true,
+		RouteManifest:             "routes.json",
+	}}
+	baseFS := fstest.MapFS{
+		"index.html":  &fstest.MapFile{Data: []byte("shell")},
+		"routes.json": &fstest.MapFile{Data: []byte(`{"routes": ["/", "/users/:id"]}`)},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	tests := []struct {
+		urlPath string
+		status  int
+	}{
+		{"/", http.StatusOK},
+		{"/users/42", http.StatusOK},
+		{"/unknown", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		req := newStaticRequest(http.MethodGet, tt.urlPath, tt.urlPath[1:])
+		rec := httptest.NewRecorder()
+
+		if err := fsList.serve(rec, req); err != nil {
+			t.Fatalf("unexpected error for %s: %v", tt.urlPath, err)
+		}
+
+		if rec.Code != tt.status || rec.Body.String() != "shell" {
+			t.Fatalf("expected %d with the SPA shell for %s, got %d %q", tt.status, tt.urlPath, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestNewFSListRejectsInvalidRouteManifest(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{IndexFallback: true, RouteManifest: "routes.json"}}
+	baseFS := fstest.MapFS{
+		"routes.json": &fstest.MapFile{Data: []byte(`{"routes": [`)},
+	}
+
+	if _, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS}); err == nil {
+		t.Fatal("expected an error for an invalid route manifest")
+	}
+}
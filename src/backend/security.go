@@ -0,0 +1,223 @@
+package backend
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+type cspDirective struct {
+	name   string
+	values []string
+}
+
+// securityHeaders adds the configured security headers to responses and
+// builds the Content-Security-Policy of HTML pages for a nonce.
+type securityHeaders struct {
+	cfg        configuration.SecurityConfig
+	directives []cspDirective
+}
+
+func newSecurityHeaders(cfg configuration.SecurityConfig) (securityHeaders, error) {
+	if cfg.HSTSMaxAge < 0 {
+		return securityHeaders{}, fmt.Errorf("invalid HSTS max-age %d: must not be negative", cfg.HSTSMaxAge)
+	}
+
+	s := securityHeaders{cfg: cfg}
+	if !cfg.CSP {
+		return s, nil
+	}
+
+	for _, directive := range cfg.CSPDirectives {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name := strings.ToLower(fields[0])
+		if strings.ContainsAny(name, ",;") {
+			return securityHeaders{}, fmt.Errorf("invalid Content-Security-Policy directive '%s'", directive)
+		}
+		s.directives = append(s.directives, cspDirective{name: name, values: fields[1:]})
+	}
+
+	// Without their own directive, scripts and styles fall back to
+	// default-src, so the nonce needs a directive to be added to.
+	for _, name := range []string{"script-src", "style-src"} {
+		if s.directive(name) != nil {
+			continue
+		}
+
+		var values []string
+		if defaultSrc := s.directive("default-src"); defaultSrc != nil {
+			values = slices.Clone(defaultSrc.values)
+		}
+		s.directives = append(s.directives, cspDirective{name: name, values: values})
+	}
+
+	return s, nil
+}
+
+func (s *securityHeaders) directive(name string) *cspDirective {
+	for i := range s.directives {
+		if s.directives[i].name == name {
+			return &s.directives[i]
+		}
+	}
+	return nil
+}
+
+// nonces reports whether HTML pages get per-response nonces.
+func (s *securityHeaders) nonces() bool {
+	return s.cfg.CSP
+}
+
+// contentSecurityPolicy returns the policy allowing the elements carrying
+// nonce. A style-src allowing 'unsafe-inline' is left as it is, as browsers
+// ignore 'unsafe-inline' once a nonce is present, which would break style
+// elements injected at runtime by libraries.
+func (s *securityHeaders) contentSecurityPolicy(nonce string) string {
+	var sb strings.Builder
+
+	for i, directive := range s.directives {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(directive.name)
+
+		for _, value := range directive.values {
+			sb.WriteByte(' ')
+			sb.WriteString(value)
+		}
+
+		switch directive.name {
+		case "style-src":
+			if slices.Contains(directive.values, "'unsafe-inline'") {
+				break
+			}
+			fallthrough
+		case "script-src":
+			sb.WriteString(" 'nonce-")
+			sb.WriteString(nonce)
+			sb.WriteByte('\'')
+		}
+	}
+
+	return sb.String()
+}
+
+// setContentSecurityPolicy sets the policy header of an HTML page.
+func (s *securityHeaders) setContentSecurityPolicy(header http.Header, nonce string) {
+	name := "Content-Security-Policy"
+	if s.cfg.CSPReportOnly {
+		name = "Content-Security-Policy-Report-Only"
+	}
+	header.Set(name, s.contentSecurityPolicy(nonce))
+}
+
+// apply sets the headers that are independent of the response content.
+func (s *securityHeaders) apply(header http.Header, r *http.Request) {
+	if s.cfg.ContentTypeNosniff {
+		header.Set("X-Content-Type-Options", "nosniff")
+	}
+	if s.cfg.ReferrerPolicy != "" {
+		header.Set("Referrer-Policy", s.cfg.ReferrerPolicy)
+	}
+	if s.cfg.PermissionsPolicy != "" {
+		header.Set("Permissions-Policy", s.cfg.PermissionsPolicy)
+	}
+
+	// Browsers ignore HSTS received over plain HTTP, behind a TLS terminating
+	// proxy the forwarded protocol tells the difference.
+	if s.cfg.HSTSMaxAge > 0 && (r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")) {
+		value := "max-age=" + strconv.Itoa(s.cfg.HSTSMaxAge)
+		if s.cfg.HSTSIncludeSubdomains {
+			value += "; includeSubDomains"
+		}
+		header.Set("Strict-Transport-Security", value)
+	}
+}
+
+// SecurityHeaders returns a middleware adding the configured security headers
+// to every response. The Content-Security-Policy is only sent with the HTML
+// pages served by Static, as it carries their nonce.
+func (f *FSList) SecurityHeaders() func(*core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		f.security.apply(e.Response.Header(), e.Request)
+		return e.Next()
+	}
+}
+
+// newNonce returns a random nonce for a single response.
+func newNonce() (string, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(nonce[:]), nil
+}
+
+var (
+	// nonceElementPattern matches comments, which are skipped, and the start
+	// tags of script and style elements.
+	nonceElementPattern = regexp.MustCompile(`(?is)<!--.*?-->|<(script|style)(?:[\s/][^>]*)?>`)
+	nonceEndTagPatterns = map[string]*regexp.Regexp{
+		"script": regexp.MustCompile(`(?i)</script`),
+		"style":  regexp.MustCompile(`(?i)</style`),
+	}
+	nonceAttribute = htmlAttributePattern("nonce")
+)
+
+// addNonces adds the nonce attribute to every script and style element of
+// doc, and publishes the nonce in a meta element for scripts that create
+// elements at runtime (Vite reads it from "csp-nonce" in dev mode). Elements
+// that already carry a nonce are left alone, and the contents of script and
+// style elements are skipped, as they may spell out tags in strings.
+func addNonces(doc []byte, nonce string) []byte {
+	result := make([]byte, 0, len(doc)+256)
+	for len(doc) > 0 {
+		match := nonceElementPattern.FindSubmatchIndex(doc)
+		if match == nil {
+			break
+		}
+
+		result = append(result, doc[:match[0]]...)
+		tag := doc[match[0]:match[1]]
+		doc = doc[match[1]:]
+
+		// a comment
+		if match[2] == -1 {
+			result = append(result, tag...)
+			continue
+		}
+
+		if _, ok := htmlAttribute(tag, nonceAttribute); ok {
+			result = append(result, tag...)
+		} else {
+			// insert right behind the element name, "<script" or "<style"
+			index := match[3] - match[0]
+			result = append(result, tag[:index]...)
+			result = append(result, ` nonce="`+nonce+`"`...)
+			result = append(result, tag[index:]...)
+		}
+
+		name := strings.ToLower(string(tag[1 : match[3]-match[0]]))
+		end := nonceEndTagPatterns[name].FindIndex(doc)
+		if end == nil {
+			break
+		}
+		result = append(result, doc[:end[0]]...)
+		doc = doc[end[0]:]
+	}
+	doc = append(result, doc...)
+
+	return injectBeforeClosingTag(doc, "</head>", []byte(`<meta property="csp-nonce" nonce="`+nonce+`" />`))
+}
@@ -0,0 +1,227 @@
+package backend
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestContentSecurityPolicyAddsNonce(t *testing.T) {
+	tests := []struct {
+		directives []string
+		expected   string
+	}{
+		{
+			directives: []string{"default-src 'self'", "script-src 'self'", "style-src 'self' 'unsafe-inline'"},
+			expected:   "default-src 'self'; script-src 'self' 'nonce-abc'; style-src 'self' 'unsafe-inline'",
+		},
+		{
+			directives: []string{"default-src 'self'", "style-src 'self'"},
+			expected:   "default-src 'self'; style-src 'self' 'nonce-abc'; script-src 'self' 'nonce-abc'",
+		},
+		{
+			directives: []string{"  Img-Src  data:  ", ""},
+			expected:   "img-src data:; script-src 'nonce-abc'; style-src 'nonce-abc'",
+		},
+	}
+
+	for _, test := range tests {
+		security, err := newSecurityHeaders(configuration.SecurityConfig{CSP: true, CSPDirectives: test.directives})
+		if err != nil {
+			t.Fatalf("unexpected error for %v: %v", test.directives, err)
+		}
+
+		if got := security.contentSecurityPolicy("abc"); got != test.expected {
+			t.Errorf("directives %v: expected %q, got %q", test.directives, test.expected, got)
+		}
+	}
+
+	if _, err := newSecurityHeaders(configuration.SecurityConfig{CSP: true, CSPDirectives: []string{"script-src;"}}); err == nil {
+		t.Fatal("expected an error for a directive containing a separator")
+	}
+}
+
+func TestAddNoncesOnlyRewritesStartTags(t *testing.T) {
+	doc := `<head><!-- <script> --><SCRIPT type="module">const tag = "<script src=x>"; const css = '<style>';</script>` +
+		`<style nonce="fixed">a{}</style><scripts></scripts><style>b{}</style></head>`
+	expected := `<head><!-- <script> --><SCRIPT nonce="abc" type="module">const tag = "<script src=x>"; const css = '<style>';</script>` +
+		`<style nonce="fixed">a{}</style><scripts></scripts><style nonce="abc">b{}</style>` +
+		`<meta property="csp-nonce" nonce="abc" /></head>`
+
+	if got := string(addNonces([]byte(doc), "abc")); got != expected {
+		t.Fatalf("unexpected document:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestSecurityHeadersApply(t *testing.T) {
+	security, err := newSecurityHeaders(configuration.SecurityConfig{
+		HSTSMaxAge:            3600,
+		HSTSIncludeSubdomains: true,
+		ContentTypeNosniff:    true,
+		ReferrerPolicy:        "no-referrer",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	header := http.Header{}
+	security.apply(header, req)
+
+	if got := header.Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Fatalf("expected nosniff, got %q", got)
+	}
+	if got := header.Get("Referrer-Policy"); got != "no-referrer" {
+		t.Fatalf("expected the referrer policy, got %q", got)
+	}
+	if _, ok := header["Permissions-Policy"]; ok {
+		t.Fatal("expected no Permissions-Policy if it is empty")
+	}
+	if got := header.Get("Strict-Transport-Security"); got != "" {
+		t.Fatalf("expected no HSTS over plain HTTP, got %q", got)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "https://example.com/", nil),
+		httptest.NewRequest(http.MethodGet, "/", nil),
+	} {
+		if req.TLS == nil {
+			req.Header.Set("X-Forwarded-Proto", "https")
+		}
+
+		header := http.Header{}
+		security.apply(header, req)
+		if got := header.Get("Strict-Transport-Security"); got != "max-age=3600; includeSubDomains" {
+			t.Fatalf("expected HSTS over HTTPS, got %q", got)
+		}
+	}
+}
+
+func TestStaticCompressesAndRevalidatesPagesWithNonces(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		StaticFileServerImmutable: true,
+		Security:                  configuration.SecurityConfig{CSP: true, CSPDirectives: []string{"default-src 'self'"}},
+	}}
+	page := `<html><head><title>App</title></head><body><script src="/app.js"></script>` + strings.Repeat("<p>padding</p>", 64) + `</body></html>`
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte(page)},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	serve := func(header http.Header) *httptest.ResponseRecorder {
+		req := newStaticRequest(http.MethodGet, "/", "")
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		if err := fsList.serve(rec, req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return rec
+	}
+
+	rec := serve(http.Header{"Accept-Encoding": {"gzip, br"}})
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected gzip content encoding, got %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Fatalf("expected Vary header, got %q", got)
+	}
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("open gzip reader: %v", err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil || !strings.Contains(string(body), `<script nonce="`) {
+		t.Fatalf("expected the page with nonces, got %v: %q", err, body)
+	}
+
+	etag := rec.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) || !strings.HasSuffix(etag, `-gzip"`) {
+		t.Fatalf("expected a weak ETag of the gzip representation, got %q", etag)
+	}
+	if identity := serve(nil).Header().Get("ETag"); identity == etag || !strings.HasPrefix(identity, `W/"`) {
+		t.Fatalf("expected a distinct weak ETag for the identity representation, got %q", identity)
+	}
+
+	rec = serve(http.Header{"Accept-Encoding": {"gzip"}, "If-None-Match": {etag}})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching ETag, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); got != "" {
+		t.Fatalf("expected the stored policy to be kept, got %q", got)
+	}
+}
+
+func TestStaticAddsFreshNonces(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		StaticFileServerImmutable: true,
+		Security: configuration.SecurityConfig{
+			CSP:           true,
+			CSPDirectives: []string{"default-src 'self'", "script-src 'self'", "style-src 'self'"},
+		},
+	}}
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte(`<html><head><STYLE>a{}</STYLE></head><body><script type="module" src="/app.js"></script></body></html>`)},
+		"app.js":     &fstest.MapFile{Data: []byte("app")},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	noncePattern := regexp.MustCompile(`'nonce-([^']+)'`)
+	seen := make(map[string]bool)
+	etags := make(map[string]bool)
+
+	for range 2 {
+		rec := httptest.NewRecorder()
+		if err := fsList.serve(rec, newStaticRequest(http.MethodGet, "/", "")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		policy := rec.Header().Get("Content-Security-Policy")
+		match := noncePattern.FindStringSubmatch(policy)
+		if match == nil {
+			t.Fatalf("expected a nonce in the policy, got %q", policy)
+		}
+		nonce := match[1]
+		if seen[nonce] {
+			t.Fatalf("expected a fresh nonce per response, got %q twice", nonce)
+		}
+		seen[nonce] = true
+
+		body := rec.Body.String()
+		for _, expected := range []string{
+			`<STYLE nonce="` + nonce + `">`,
+			`<script nonce="` + nonce + `" type="module"`,
+			`<meta property="csp-nonce" nonce="` + nonce + `" /></head>`,
+		} {
+			if !strings.Contains(body, expected) {
+				t.Fatalf("expected %q in body, got %q", expected, body)
+			}
+		}
+		etags[rec.Header().Get("ETag")] = true
+	}
+	if len(etags) != 1 {
+		t.Fatalf("expected the nonces to not change the ETag, got %v", etags)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := fsList.serve(rec, newStaticRequest(http.MethodGet, "/app.js", "app.js")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); got != "" {
+		t.Fatalf("expected no policy for scripts, got %q", got)
+	}
+}
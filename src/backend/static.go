@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+)
+
+// Static returns a route handler serving the filesystem stack. It behaves like
+// apis.Static (the route needs a "{path...}" wildcard parameter), but is aware
+// of the FSList internals, e.g. to negotiate precompressed representations.
+func (f *FSList) Static() func(*core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		return f.serve(e.Response, e.Request)
+	}
+}
+
+func (f *FSList) serve(w http.ResponseWriter, r *http.Request) error {
+	name := r.PathValue(apis.StaticWildcardParam)
+	name = filepath.ToSlash(filepath.Clean(strings.TrimPrefix(name, "/")))
+
+	// eagerly check for directory traversal, same as apis.Static
+	if len(name) > 2 && name[0] == '.' && name[1] == '.' && (name[2] == '/' || name[2] == '\\') {
+		if !f.cfg.Server.IndexFallback {
+			return router.ErrFileNotFound
+		}
+		name = router.IndexPage
+	}
+
+	name, done := f.redirect(w, r, name)
+	if done {
+		return nil
+	}
+
+	// in dev mode modules and assets come straight from the Vite dev server
+	if f.viteProxy != nil && f.viteProxy.serve(w, r, name) {
+		return nil
+	}
+
+	requested := name
+	acceptEncoding := r.Header.Get("Accept-Encoding")
+
+	rep, err := f.openRequested(r, name, acceptEncoding)
+	if err != nil {
+		return f.serveNotFound(w, r, acceptEncoding)
+	}
+	file := rep.file
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if info.IsDir() {
+		file.Close()
+
+		// redirect to a canonical dir url, aka. with trailing slash
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, safeRedirectPath(r.URL.Path+"/"), http.StatusMovedPermanently)
+			return nil
+		}
+
+		name = path.Join(name, router.IndexPage)
+		rep, err = f.openRequested(r, name, acceptEncoding)
+		if err != nil {
+			return f.serveNotFound(w, r, acceptEncoding)
+		}
+		file = rep.file
+
+		info, err = file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+	} else if urlPath := r.URL.Path; strings.HasSuffix(urlPath, "/") {
+		// redirect to a non-trailing slash file route
+		urlPath = strings.TrimRight(urlPath, "/")
+		if len(urlPath) > 0 {
+			file.Close()
+			http.Redirect(w, r, safeRedirectPath(urlPath), http.StatusMovedPermanently)
+			return nil
+		}
+	} else if stripped, ok := strings.CutSuffix(urlPath, router.IndexPage); ok {
+		// redirect without the index.html
+		file.Close()
+		http.Redirect(w, r, safeRedirectPath(stripped), http.StatusMovedPermanently)
+		return nil
+	}
+	defer file.Close()
+
+	// the SPA shell still renders its own not found page, but crawlers and
+	// monitors should see the status of a route the frontend does not know
+	status := http.StatusOK
+	if rep.fallback && !f.knownRoute(requested) {
+		status = http.StatusNotFound
+	}
+
+	if meta := f.pageMeta(r, requested, rep); meta != nil || f.dynamicHTML(rep) {
+		return f.serveDynamicHTML(w, r, rep, meta, status)
+	}
+
+	if status != http.StatusOK {
+		return writeWithStatus(w, r, rep, status)
+	}
+
+	content := seekable(rep, info.Size())
+	if forward, ok := content.(*forwardSeeker); ok {
+		defer forward.Close()
+	}
+
+	header := w.Header()
+	if isCompressible(info.Name()) {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if rep.encoding != "" {
+		header.Set("Content-Encoding", rep.encoding)
+	}
+
+	// http.ServeContent answers If-None-Match and If-Range with the ETag header.
+	if etag := f.entityTag(rep); etag != "" {
+		header.Set("ETag", etag)
+	}
+
+	header.Set("Cache-Control", f.cachePolicy.cacheControl(rep.name, rep.item.immutable))
+
+	// Embedded files carry no modification time, so they are reported as
+	// modified when the server started to keep If-Modified-Since working.
+	modTime := info.ModTime()
+	if modTime.IsZero() && rep.item.immutable {
+		modTime = f.createdAt
+	}
+
+	http.ServeContent(w, r, info.Name(), modTime, content)
+
+	return nil
+}
+
+// safeRedirectPath prevents open redirects through protocol-relative paths
+// like "//example.com", mirroring the helper used by apis.Static.
+func safeRedirectPath(path string) string {
+	if len(path) > 1 && (path[1] == '/' || path[1] == '\\') {
+		path = "/" + strings.TrimLeft(path, "/\\")
+	}
+	return path
+}
@@ -0,0 +1,275 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+type staticLayer struct {
+	priority int
+	item     FSItem
+
+	// archive is set for archive layers, which are reloaded when replaced.
+	archive *archiveLayer
+}
+
+// StaticLayers are the configured filesystems added to the static file stack
+// next to the app bundle.
+type StaticLayers struct {
+	layers []staticLayer
+}
+
+func newStaticLayers(cfgs []configuration.StaticLayerConfig) (*StaticLayers, error) {
+	s := &StaticLayers{layers: make([]staticLayer, 0, len(cfgs))}
+
+	for _, cfg := range cfgs {
+		if cfg.Path == "" {
+			return nil, errors.New("static layer without a path")
+		}
+
+		info, err := os.Stat(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open static layer '%s': %w", cfg.Path, err)
+		}
+
+		layer := staticLayer{priority: cfg.Priority}
+		switch {
+		case info.IsDir():
+			layer.item = newDirFSItem(cfg.Path)
+		case isArchive(cfg.Path):
+			layer.archive, err = newArchiveLayer(cfg.Path)
+			if err != nil {
+				return nil, err
+			}
+			layer.item = FSItem{fs: layer.archive}
+		default:
+			return nil, fmt.Errorf("static layer '%s' is neither a directory nor a supported archive", cfg.Path)
+		}
+
+		// names reported by the watcher are relative to the directory, so a
+		// mounted directory is not watched
+		if mount := strings.Trim(path.Clean("/"+cfg.Mount), "/"); mount != "" {
+			layer.item = FSItem{fs: mountFS{fsys: layer.item.fs, mount: mount}}
+		}
+		layer.item.immutable = cfg.Immutable
+
+		s.layers = append(s.layers, layer)
+	}
+
+	slices.SortStableFunc(s.layers, func(a staticLayer, b staticLayer) int {
+		return b.priority - a.priority
+	})
+
+	return s, nil
+}
+
+// stack returns appItems with the layers of a positive priority in front and
+// the others behind them.
+func (s *StaticLayers) stack(appItems []FSItem) []FSItem {
+	items := make([]FSItem, 0, len(appItems)+len(s.layers))
+	for _, layer := range s.layers {
+		if layer.priority > 0 {
+			items = append(items, layer.item)
+		}
+	}
+
+	items = append(items, appItems...)
+
+	for _, layer := range s.layers {
+		if layer.priority <= 0 {
+			items = append(items, layer.item)
+		}
+	}
+	return items
+}
+
+// watch polls the archives until ctx is done and calls onSwap with the path
+// of every archive that has been reloaded.
+func (s *StaticLayers) watch(ctx context.Context, onSwap func(path string)) {
+	var archives []*archiveLayer
+	for _, layer := range s.layers {
+		if layer.archive != nil {
+			archives = append(archives, layer.archive)
+		}
+	}
+
+	if len(archives) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			for _, archive := range archives {
+				swapped, err := archive.reload()
+				if err != nil {
+					log.Printf("Failed to reload the static layer '%s': %v\n", archive.path, err)
+					continue
+				}
+				if swapped && onSwap != nil {
+					onSwap(archive.path)
+				}
+			}
+		}
+	}()
+}
+
+// archiveLayer serves the contents of an archive file. A replaced archive is
+// swapped in atomically, every Open sees either the old or the new archive.
+type archiveLayer struct {
+	path    string
+	current atomic.Pointer[archiveSnapshot]
+
+	// loaded is the state of the current archive file and failed the state
+	// that could not be opened. They are only accessed by reload, which is
+	// not called concurrently.
+	loaded fileState
+	failed fileState
+}
+
+// archiveSnapshot is a loaded archive. It is closed once it has been replaced
+// and the last file opened from it is closed.
+type archiveSnapshot struct {
+	fs     fs.FS
+	closer io.Closer
+
+	// refs counts the open files, plus one while the snapshot is current.
+	refs atomic.Int64
+}
+
+func newArchiveSnapshot(fsys fs.FS, closer io.Closer) *archiveSnapshot {
+	snapshot := &archiveSnapshot{fs: fsys, closer: closer}
+	snapshot.refs.Store(1)
+	return snapshot
+}
+
+// acquire takes a reference unless the snapshot has already been closed.
+func (s *archiveSnapshot) acquire() bool {
+	for {
+		refs := s.refs.Load()
+		if refs == 0 {
+			return false
+		}
+		if s.refs.CompareAndSwap(refs, refs+1) {
+			return true
+		}
+	}
+}
+
+func (s *archiveSnapshot) release() {
+	if s.refs.Add(-1) == 0 && s.closer != nil {
+		s.closer.Close()
+	}
+}
+
+// open opens name of the snapshot, which holds a reference until the file is
+// closed. Archives read into memory and directories are not tracked.
+func (s *archiveSnapshot) open(name string) (fs.File, error) {
+	file, err := s.fs.Open(name)
+	if err != nil || s.closer == nil {
+		return file, err
+	}
+	if _, ok := file.(fs.ReadDirFile); ok {
+		return file, nil
+	}
+
+	s.refs.Add(1)
+	return &archiveFile{File: file, snapshot: s, name: name}, nil
+}
+
+// archiveFile is a file of an archiveSnapshot, which is kept open until the
+// file is closed.
+type archiveFile struct {
+	fs.File
+	snapshot *archiveSnapshot
+	name     string
+	closed   atomic.Bool
+}
+
+func (f *archiveFile) Close() error {
+	if !f.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	err := f.File.Close()
+	f.snapshot.release()
+	return err
+}
+
+// reopen opens the file again from the same archive, even if it has been
+// replaced since, see forwardSeeker.
+func (f *archiveFile) reopen() (fs.File, error) {
+	return f.snapshot.open(f.name)
+}
+
+func newArchiveLayer(name string) (*archiveLayer, error) {
+	a := &archiveLayer{path: name}
+	if _, err := a.reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *archiveLayer) Open(name string) (fs.File, error) {
+	for {
+		snapshot := a.current.Load()
+		if !snapshot.acquire() {
+			// replaced and closed since it was loaded, the current one is newer
+			continue
+		}
+
+		file, err := snapshot.open(name)
+		snapshot.release()
+		return file, err
+	}
+}
+
+// reload opens the archive again if the file changed since it was loaded and
+// reports whether it has been swapped. An archive that cannot be opened, e.g.
+// as it is still being written, is retried once the file changes again.
+func (a *archiveLayer) reload() (bool, error) {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat archive '%s': %w", a.path, err)
+	}
+
+	state := fileState{modTime: info.ModTime(), size: info.Size()}
+	if a.current.Load() != nil && (state == a.loaded || state == a.failed) {
+		return false, nil
+	}
+
+	fsys, closer, err := openArchive(a.path)
+	if err != nil {
+		a.failed = state
+		return false, err
+	}
+
+	previous := a.current.Swap(newArchiveSnapshot(fsys, closer))
+	a.loaded = state
+
+	// closed right away or once the responses still reading it are done
+	if previous != nil {
+		previous.release()
+	}
+
+	return true, nil
+}
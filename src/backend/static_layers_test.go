@@ -0,0 +1,185 @@
+package backend
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func writeZip(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+
+	// written next to the archive and renamed, like a deployment would do
+	tmp := name + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+
+	zw := zip.NewWriter(file)
+	for fileName, content := range files {
+		w, err := zw.Create(fileName)
+		if err != nil {
+			t.Fatalf("failed to add '%s' to archive: %v", fileName, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write '%s' to archive: %v", fileName, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip writer: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+
+	if err := os.Rename(tmp, name); err != nil {
+		t.Fatalf("failed to replace archive: %v", err)
+	}
+}
+
+func TestStaticLayersStackByPriority(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "theme.zip"), map[string]string{"app.css": "theme"})
+	writeTarGz(t, filepath.Join(dir, "docs.tgz"), map[string]string{"index.html": "docs"})
+
+	layers, err := newStaticLayers([]configuration.StaticLayerConfig{
+		{Path: filepath.Join(dir, "docs.tgz"), Mount: "/docs/"},
+		{Path: filepath.Join(dir, "theme.zip"), Priority: 10, Immutable: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating static layers: %v", err)
+	}
+
+	app := FSItem{fs: fstest.MapFS{
+		"app.css":    &fstest.MapFile{Data: []byte("app")},
+		"index.html": &fstest.MapFile{Data: []byte("app")},
+	}}
+
+	items := layers.stack([]FSItem{app})
+	if len(items) != 3 || !items[0].immutable || items[2].immutable {
+		t.Fatalf("expected the theme, the app and the docs layer, got %+v", items)
+	}
+
+	fsList, err := NewFSList(true, configuration.AppConfig{}, nil, items...)
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	for name, expected := range map[string]string{
+		"app.css":         "theme",
+		"index.html":      "app",
+		"docs/index.html": "docs",
+	} {
+		if got := readAll(t, &fsList, name); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+
+	if _, err := newStaticLayers([]configuration.StaticLayerConfig{{Path: filepath.Join(dir, "missing.zip")}}); err == nil {
+		t.Fatal("expected an error for a missing archive")
+	}
+}
+
+func TestArchiveLayerSwapsReplacedArchive(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "bundle.zip")
+	writeZip(t, name, map[string]string{"index.html": "v1"})
+
+	layer, err := newArchiveLayer(name)
+	if err != nil {
+		t.Fatalf("unexpected error opening archive layer: %v", err)
+	}
+
+	if swapped, err := layer.reload(); swapped || err != nil {
+		t.Fatalf("expected an unchanged archive to be kept, got swapped %v and error %v", swapped, err)
+	}
+
+	writeZip(t, name, map[string]string{"index.html": "version 2"})
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatalf("failed to touch archive: %v", err)
+	}
+
+	if swapped, err := layer.reload(); !swapped || err != nil {
+		t.Fatalf("expected the replaced archive to be swapped in, got swapped %v and error %v", swapped, err)
+	}
+	if data, err := fs.ReadFile(layer, "index.html"); err != nil || string(data) != "version 2" {
+		t.Fatalf("expected the new content, got %q and error %v", data, err)
+	}
+
+	if err := os.WriteFile(name+".tmp", []byte("not a zip"), 0o644); err != nil {
+		t.Fatalf("failed to write corrupt archive: %v", err)
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		t.Fatalf("failed to replace archive: %v", err)
+	}
+	if _, err := layer.reload(); err == nil {
+		t.Fatal("expected an error for a corrupt archive")
+	}
+	if swapped, err := layer.reload(); swapped || err != nil {
+		t.Fatalf("expected a corrupt archive to be retried only after it changed, got swapped %v and error %v", swapped, err)
+	}
+	if data, err := fs.ReadFile(layer, "index.html"); err != nil || string(data) != "version 2" {
+		t.Fatalf("expected the last good archive to be served, got %q and error %v", data, err)
+	}
+}
+
+func TestArchiveLayerKeepsReplacedArchiveOpenForReaders(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "bundle.zip")
+	writeZip(t, name, map[string]string{"video.mp4": "old video"})
+
+	layer, err := newArchiveLayer(name)
+	if err != nil {
+		t.Fatalf("unexpected error opening archive layer: %v", err)
+	}
+
+	file, err := layer.Open("video.mp4")
+	if err != nil {
+		t.Fatalf("unexpected error opening file: %v", err)
+	}
+	previous := layer.current.Load()
+
+	writeZip(t, name, map[string]string{"video.mp4": "new video"})
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatalf("failed to touch archive: %v", err)
+	}
+	if swapped, err := layer.reload(); !swapped || err != nil {
+		t.Fatalf("expected the replaced archive to be swapped in, got swapped %v and error %v", swapped, err)
+	}
+
+	// a range request rewinds through the archive it started on
+	content := seekable(representation{file: file, name: "video.mp4", item: FSCacheItem{fs: layer}}, int64(len("old video")))
+	if _, err := content.Seek(4, io.SeekStart); err != nil {
+		t.Fatalf("unexpected error seeking: %v", err)
+	}
+	if data, err := io.ReadAll(content); err != nil || string(data) != "video" {
+		t.Fatalf("expected the rest of the old file, got %q and error %v", data, err)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("unexpected error seeking: %v", err)
+	}
+	if data, err := io.ReadAll(content); err != nil || string(data) != "old video" {
+		t.Fatalf("expected the old file after rewinding, got %q and error %v", data, err)
+	}
+
+	if refs := previous.refs.Load(); refs != 2 {
+		t.Fatalf("expected the replaced archive to be held by both files, got %d references", refs)
+	}
+	content.(io.Closer).Close()
+	file.Close()
+	if refs := previous.refs.Load(); refs != 0 {
+		t.Fatalf("expected the replaced archive to be closed with its last file, got %d references", refs)
+	}
+
+	if data, err := fs.ReadFile(layer, "video.mp4"); err != nil || string(data) != "new video" {
+		t.Fatalf("expected the new content, got %q and error %v", data, err)
+	}
+}
@@ -0,0 +1,362 @@
+package backend
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func newStaticRequest(method string, urlPath string, name string) *http.Request {
+	req := httptest.NewRequest(method, urlPath, nil)
+	req.SetPathValue("path", name)
+	return req
+}
+
+func TestStaticServesPrecompressedVariant(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
+	baseFS := fstest.MapFS{
+		"app.css":    &fstest.MapFile{Data: []byte("body{}")},
+		"app.css.br": &fstest.MapFile{Data: []byte("br")},
+		"logo.png":   &fstest.MapFile{Data: []byte("png")},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	req := newStaticRequest(http.MethodGet, "/app.css", "app.css")
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	rec := httptest.NewRecorder()
+
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := rec.Header().Get("Content-Encoding"); got != "br" {
+		t.Fatalf("expected br content encoding, got %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Fatalf("expected Vary header, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/css; charset=utf-8" {
+		t.Fatalf("expected content type of the original file, got %q", got)
+	}
+	if rec.Body.String() != "br" {
+		t.Fatalf("unexpected body: %q", rec.Body.String())
+	}
+
+	req = newStaticRequest(http.MethodGet, "/logo.png", "logo.png")
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	rec = httptest.NewRecorder()
+
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Vary") != "" {
+		t.Fatalf("expected no encoding headers for binary files, got %v", rec.Header())
+	}
+}
+
+func TestStaticGzipsImmutableFilesUpToTheLimit(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
+	script := strings.Repeat("console.log('app');\n", 64)
+	baseFS := fstest.MapFS{
+		"app.js":   &fstest.MapFile{Data: []byte(script)},
+		"large.js": &fstest.MapFile{Data: make([]byte, maxCompressedFileSize+1)},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	req := newStaticRequest(http.MethodGet, "/app.js", "app.js")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected gzip content encoding, got %q", got)
+	}
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("open gzip reader: %v", err)
+	}
+	if data, err := io.ReadAll(reader); err != nil || string(data) != script {
+		t.Fatalf("expected the compressed script, got %v", err)
+	}
+
+	req = newStaticRequest(http.MethodHead, "/large.js", "large.js")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("expected files above the limit to be served as they are, got %q", got)
+	}
+}
+
+func TestStaticRedirects(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
+	baseFS := fstest.MapFS{
+		"index.html":      &fstest.MapFile{Data: []byte("root")},
+		"docs/index.html": &fstest.MapFile{Data: []byte("docs")},
+		"file.txt":        &fstest.MapFile{Data: []byte("file")},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	tests := []struct {
+		urlPath  string
+		name     string
+		location string
+	}{
+		{urlPath: "/docs", name: "docs", location: "/docs/"},
+		{urlPath: "/file.txt/", name: "file.txt", location: "/file.txt"},
+		{urlPath: "/docs/index.html", name: "docs/index.html", location: "/docs/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.urlPath, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if err := fsList.serve(rec, newStaticRequest(http.MethodGet, tt.urlPath, tt.name)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != tt.location {
+				t.Fatalf("expected redirect to %q, got %d %q", tt.location, rec.Code, rec.Header().Get("Location"))
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	if err := fsList.serve(rec, newStaticRequest(http.MethodGet, "/docs/", "docs/")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "docs" {
+		t.Fatalf("expected directory index, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStaticRevalidation(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
+	baseFS := fstest.MapFS{
+		"app.js":    &fstest.MapFile{Data: []byte("console.log('app')")},
+		"app.js.br": &fstest.MapFile{Data: []byte("br")},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := fsList.serve(rec, newStaticRequest(http.MethodGet, "/app.js", "app.js")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	etag := rec.Header().Get("ETag")
+	lastModified := rec.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("expected validators for embedded file, got ETag %q and Last-Modified %q", etag, lastModified)
+	}
+
+	req := newStaticRequest(http.MethodGet, "/app.js", "app.js")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching ETag, got %d", rec.Code)
+	}
+
+	req = newStaticRequest(http.MethodGet, "/app.js", "app.js")
+	req.Header.Set("If-Modified-Since", lastModified)
+	rec = httptest.NewRecorder()
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for If-Modified-Since, got %d", rec.Code)
+	}
+
+	req = newStaticRequest(http.MethodGet, "/app.js", "app.js")
+	req.Header.Set("Accept-Encoding", "br")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected identity ETag not to match the brotli representation, got %d", rec.Code)
+	}
+	if encodedETag := rec.Header().Get("ETag"); encodedETag == etag || encodedETag == "" {
+		t.Fatalf("expected a distinct ETag for the brotli representation, got %q", encodedETag)
+	}
+}
+
+func TestStaticETagOfLargeFiles(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{StaticFileServerImmutable: true}}
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	small := []byte("console.log('app')")
+	baseFS := fstest.MapFS{
+		"app.js":    &fstest.MapFile{Data: small},
+		"video.mp4": &fstest.MapFile{Data: make([]byte, maxHashedFileSize+1), ModTime: modTime},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := fsList.serve(rec, newStaticRequest(http.MethodGet, "/app.js", "app.js")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if etag := rec.Header().Get("ETag"); etag != contentETag(small) {
+		t.Fatalf("expected the content hash as ETag, got %q", etag)
+	}
+
+	rec = httptest.NewRecorder()
+	if err := fsList.serve(rec, newStaticRequest(http.MethodHead, "/video.mp4", "video.mp4")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `"` + strconv.FormatInt(modTime.UnixNano(), 36) + "-" + strconv.FormatInt(maxHashedFileSize+1, 36) + `"`
+	if etag := rec.Header().Get("ETag"); etag != expected {
+		t.Fatalf("expected an ETag of the size and modification time, got %q", etag)
+	}
+
+	req := newStaticRequest(http.MethodGet, "/video.mp4", "video.mp4")
+	req.Header.Set("If-None-Match", expected)
+	rec = httptest.NewRecorder()
+	if err := fsList.serve(rec, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching ETag, got %d", rec.Code)
+	}
+}
+
+func TestStaticSetsCacheControl(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		StaticFileServerImmutable: true,
+		IndexFallback:             true,
+		StaticCache:               configuration.StaticCacheConfig{HashedAssets: true},
+	}}
+	baseFS := fstest.MapFS{
+		"index.html":               &fstest.MapFile{Data: []byte("<html></html>")},
+		"assets/index-BfX3a9Qk.js": &fstest.MapFile{Data: []byte("console.log('app')")},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	tests := []struct {
+		urlPath  string
+		name     string
+		expected string
+	}{
+		{urlPath: "/assets/index-BfX3a9Qk.js", name: "assets/index-BfX3a9Qk.js", expected: "public, max-age=31536000, immutable"},
+		{urlPath: "/", name: "", expected: "no-cache"},
+		{urlPath: "/some/route", name: "some/route", expected: "no-cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.urlPath, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if err := fsList.serve(rec, newStaticRequest(http.MethodGet, tt.urlPath, tt.name)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := rec.Header().Get("Cache-Control"); got != tt.expected {
+				t.Fatalf("expected Cache-Control %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStaticIndexFallbackOnlyForNavigation(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		StaticFileServerImmutable: true,
+		IndexFallback:             true,
+		IndexFallbackRoutes:       []string{"/docs/"},
+		IndexFallbackExclude:      []string{"api/"},
+		NotFoundPage:              "404.html",
+	}}
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("index")},
+		"404.html":   &fstest.MapFile{Data: []byte("not found")},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	tests := []struct {
+		urlPath string
+		accept  string
+		status  int
+		body    string
+	}{
+		{"/about", "", http.StatusOK, "index"},
+		{"/user/jane.doe", "text/html,application/xhtml+xml", http.StatusOK, "index"},
+		{"/docs/v1.2", "*/*", http.StatusOK, "index"},
+		{"/assets/missing.js", "*/*", http.StatusNotFound, "not found"},
+		{"/favicon.png", "image/avif,image/webp,*/*", http.StatusNotFound, "not found"},
+		{"/api/unknown", "text/html", http.StatusNotFound, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.urlPath, func(t *testing.T) {
+			req := newStaticRequest(http.MethodGet, tt.urlPath, tt.urlPath[1:])
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			if err := fsList.serve(rec, req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Fatalf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestStaticMissingWithoutNotFoundPage(t *testing.T) {
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{IndexFallback: true, NotFoundPage: "404.html"}}
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("index")},
+	}
+	fsList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	req := newStaticRequest(http.MethodGet, "/assets/missing.js", "assets/missing.js")
+	rec := httptest.NewRecorder()
+
+	if err := fsList.serve(rec, req); !errors.Is(err, router.ErrFileNotFound) {
+		t.Fatalf("expected router.ErrFileNotFound, got %v", err)
+	}
+
+	if _, err := fsList.Open("assets/missing.js"); err == nil {
+		t.Fatal("expected Open to not fall back for assets")
+	}
+}
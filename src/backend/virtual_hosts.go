@@ -0,0 +1,209 @@
+package backend
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// The special values of ServerConfig.UnknownHost.
+const (
+	unknownHostDefault = "default"
+	unknownHostReject  = "reject"
+)
+
+type virtualHost struct {
+	patterns []string
+	list     *FSList
+	config   configuration.VirtualHostConfig
+}
+
+// VirtualHosts picks the FSList serving the static files of a request by its
+// host. Without configured virtual hosts every request is served by the
+// default FSList.
+type VirtualHosts struct {
+	hosts []virtualHost
+
+	// unknown serves the hosts matching no virtual host, nil rejects them.
+	unknown *FSList
+}
+
+// newVirtualHosts creates the FSLists of the configured virtual hosts. Virtual
+// hosts without a root are served from appItems, the filesystems of the app
+// bundle, but still get a list of their own for their settings and cache.
+func newVirtualHosts(
+	devMode bool,
+	cfg configuration.AppConfig,
+	htmlVarMap map[string]string,
+	defaultList *FSList,
+	appItems []FSItem,
+) (*VirtualHosts, error) {
+	v := &VirtualHosts{}
+	seen := make(map[string]bool)
+
+	for i, vhost := range cfg.Server.VirtualHosts {
+		if len(vhost.Hosts) == 0 {
+			return nil, fmt.Errorf("virtual host %d has no hosts", i)
+		}
+
+		patterns := make([]string, 0, len(vhost.Hosts))
+		for _, host := range vhost.Hosts {
+			pattern := normalizeHost(host)
+			if pattern == "" || seen[pattern] {
+				return nil, fmt.Errorf("invalid or duplicate host '%s' of virtual host %d", host, i)
+			}
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+
+		list, err := newVirtualHostList(devMode, cfg, htmlVarMap, vhost, appItems)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create virtual host '%s': %w", vhost.Hosts[0], err)
+		}
+
+		v.hosts = append(v.hosts, virtualHost{patterns: patterns, list: list, config: vhost})
+	}
+
+	switch unknown := normalizeHost(cfg.Server.UnknownHost); unknown {
+	case "", unknownHostDefault:
+		v.unknown = defaultList
+	case unknownHostReject:
+	default:
+		v.unknown = v.lookup(unknown)
+		if v.unknown == nil {
+			return nil, fmt.Errorf("unknown host '%s' matches no virtual host", cfg.Server.UnknownHost)
+		}
+	}
+
+	return v, nil
+}
+
+func newVirtualHostList(
+	devMode bool,
+	cfg configuration.AppConfig,
+	htmlVarMap map[string]string,
+	vhost configuration.VirtualHostConfig,
+	appItems []FSItem,
+) (*FSList, error) {
+	if vhost.IndexFallback != nil {
+		cfg.Server.IndexFallback = *vhost.IndexFallback
+	}
+	if vhost.NotFoundPage != nil {
+		cfg.Server.NotFoundPage = *vhost.NotFoundPage
+	}
+	if vhost.StaticCache != nil {
+		cfg.Server.StaticCache = *vhost.StaticCache
+	}
+
+	items := slices.Clone(appItems)
+	if vhost.Root != "" {
+		items = []FSItem{newDirFSItem(vhost.Root)}
+	}
+
+	list, err := NewFSList(devMode, cfg, virtualHostHTMLVars(htmlVarMap, vhost), items...)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// virtualHostHTMLVars returns htmlVarMap with the HTML variables of vhost
+// added or replaced.
+func virtualHostHTMLVars(htmlVarMap map[string]string, vhost configuration.VirtualHostConfig) map[string]string {
+	if len(vhost.HTMLVars) == 0 {
+		return htmlVarMap
+	}
+
+	htmlVarMap = maps.Clone(htmlVarMap)
+	if htmlVarMap == nil {
+		htmlVarMap = make(map[string]string, len(vhost.HTMLVars))
+	}
+	for key, value := range vhost.HTMLVars {
+		htmlVarMap["%"+strings.Trim(key, "%")+"%"] = value
+	}
+	return htmlVarMap
+}
+
+// reloadHTML transforms the HTML pages of all virtual hosts with the values
+// of a reloaded config. The virtual hosts themselves are kept from startup.
+func (v *VirtualHosts) reloadHTML(cfg configuration.AppConfig, htmlVarMap map[string]string) error {
+	for _, vhost := range v.hosts {
+		if err := vhost.list.reloadHTML(cfg, virtualHostHTMLVars(htmlVarMap, vhost.config)); err != nil {
+			return fmt.Errorf("failed to reload virtual host '%s': %w", vhost.config.Hosts[0], err)
+		}
+	}
+	return nil
+}
+
+// invalidate drops the cached files of all virtual hosts.
+func (v *VirtualHosts) invalidate() {
+	for _, vhost := range v.hosts {
+		vhost.list.invalidate()
+	}
+	if v.unknown != nil {
+		v.unknown.invalidate()
+	}
+}
+
+// normalizeHost lowercases host and strips its port and trailing dot.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	return strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+}
+
+// matchHost reports whether host matches pattern, where "*.example.com"
+// matches every subdomain of example.com but not example.com itself.
+func matchHost(pattern string, host string) bool {
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+domain)
+	}
+	return pattern == host
+}
+
+// lookup returns the list of the first virtual host matching host, or nil.
+func (v *VirtualHosts) lookup(host string) *FSList {
+	host = normalizeHost(host)
+	for _, vhost := range v.hosts {
+		for _, pattern := range vhost.patterns {
+			if matchHost(pattern, host) {
+				return vhost.list
+			}
+		}
+	}
+	return nil
+}
+
+// Static returns a route handler serving the static files of the request host
+// like FSList.Static. Hosts without a virtual host are answered with status
+// 421 if ServerConfig.UnknownHost rejects them.
+func (v *VirtualHosts) Static() func(*core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		return v.serve(e.Response, e.Request)
+	}
+}
+
+func (v *VirtualHosts) serve(w http.ResponseWriter, r *http.Request) error {
+	list := v.list(r.Host)
+	if list == nil {
+		return router.NewApiError(http.StatusMisdirectedRequest, "This host is not served.", nil)
+	}
+	return list.serve(w, r)
+}
+
+// list returns the list serving host, nil if the host is rejected.
+func (v *VirtualHosts) list(host string) *FSList {
+	if list := v.lookup(host); list != nil {
+		return list
+	}
+	return v.unknown
+}
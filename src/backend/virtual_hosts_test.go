@@ -0,0 +1,131 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		host     string
+		expected bool
+	}{
+		{pattern: "app.example.com", host: "app.example.com", expected: true},
+		{pattern: "app.example.com", host: "www.example.com", expected: false},
+		{pattern: "*.example.com", host: "a.b.example.com", expected: true},
+		{pattern: "*.example.com", host: "example.com", expected: false},
+		{pattern: "*.example.com", host: "badexample.com", expected: false},
+	}
+
+	for _, test := range tests {
+		if got := matchHost(test.pattern, test.host); got != test.expected {
+			t.Errorf("matchHost(%q, %q): expected %v, got %v", test.pattern, test.host, test.expected, got)
+		}
+	}
+
+	for host, expected := range map[string]string{
+		"App.Example.com:8090": "app.example.com",
+		"example.com.":         "example.com",
+		"[::1]:8090":           "::1",
+	} {
+		if got := normalizeHost(host); got != expected {
+			t.Errorf("normalizeHost(%q): expected %q, got %q", host, expected, got)
+		}
+	}
+}
+
+func TestVirtualHostsServePerHostStacks(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "index.html"), []byte("<h1>%APP_CONFIG_GENERAL_NAME%</h1>"), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	indexFallback := false
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		IndexFallback: true,
+		VirtualHosts: []configuration.VirtualHostConfig{
+			{
+				Hosts:         []string{"www.example.com", "*.example.org"},
+				Root:          root,
+				HTMLVars:      map[string]string{"APP_CONFIG_GENERAL_NAME": "Marketing"},
+				IndexFallback: &indexFallback,
+			},
+			{Hosts: []string{"app.example.com"}},
+		},
+	}}
+	htmlVarMap := map[string]string{"%APP_CONFIG_GENERAL_NAME%": "App"}
+	appItems := []FSItem{{fs: fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<h1>%APP_CONFIG_GENERAL_NAME%</h1>")},
+	}}}
+
+	defaultList, err := NewFSList(false, cfg, htmlVarMap, appItems...)
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	serveHost := func(hosts *VirtualHosts, host string, urlPath string) (*httptest.ResponseRecorder, error) {
+		req := newStaticRequest(http.MethodGet, urlPath, urlPath[1:])
+		req.Host = host
+		rec := httptest.NewRecorder()
+		return rec, hosts.serve(rec, req)
+	}
+
+	tests := []struct {
+		unknownHost string
+		host        string
+		urlPath     string
+		expected    string
+		errStatus   int
+	}{
+		{host: "www.example.com", urlPath: "/", expected: "<h1>Marketing</h1>"},
+		{host: "blog.Example.org:443", urlPath: "/", expected: "<h1>Marketing</h1>"},
+		{host: "www.example.com", urlPath: "/pricing", errStatus: http.StatusNotFound},
+		{host: "app.example.com", urlPath: "/pricing", expected: "<h1>App</h1>"},
+		{host: "other.test", urlPath: "/", expected: "<h1>App</h1>"},
+		{unknownHost: "www.example.com", host: "other.test", urlPath: "/", expected: "<h1>Marketing</h1>"},
+		{unknownHost: "reject", host: "other.test", urlPath: "/", errStatus: http.StatusMisdirectedRequest},
+	}
+
+	for _, test := range tests {
+		cfg.Server.UnknownHost = test.unknownHost
+		hosts, err := newVirtualHosts(false, cfg, htmlVarMap, &defaultList, appItems)
+		if err != nil {
+			t.Fatalf("unexpected error creating virtual hosts: %v", err)
+		}
+
+		rec, err := serveHost(hosts, test.host, test.urlPath)
+		if test.errStatus != 0 {
+			var apiErr *router.ApiError
+			if !errors.As(err, &apiErr) || apiErr.Status != test.errStatus {
+				t.Fatalf("%s%s: expected an error with status %d, got %v", test.host, test.urlPath, test.errStatus, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s%s: unexpected error: %v", test.host, test.urlPath, err)
+		}
+		if rec.Body.String() != test.expected {
+			t.Fatalf("%s%s: expected %q, got %q", test.host, test.urlPath, test.expected, rec.Body.String())
+		}
+	}
+
+	cfg.Server.UnknownHost = "missing.example.com"
+	if _, err := newVirtualHosts(false, cfg, htmlVarMap, &defaultList, appItems); err == nil {
+		t.Fatal("expected an error for an unknown host naming no virtual host")
+	}
+
+	cfg.Server.UnknownHost = ""
+	cfg.Server.VirtualHosts = append(cfg.Server.VirtualHosts, configuration.VirtualHostConfig{Hosts: []string{"WWW.example.com"}})
+	if _, err := newVirtualHosts(false, cfg, htmlVarMap, &defaultList, appItems); err == nil {
+		t.Fatal("expected an error for a duplicate host")
+	}
+}
@@ -0,0 +1,170 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// errViteNotFound aborts a proxied response the dev server answered with 404,
+// so the request can be served by the next filesystem instead.
+var errViteNotFound = errors.New("not found on the Vite dev server")
+
+type viteProxyResultKey struct{}
+
+type viteProxyResult struct {
+	notFound bool
+}
+
+// viteProxy forwards requests to a Vite dev server. It takes the place of the
+// dist directory in the FSList: HTML documents are fetched through Open, so
+// they are transformed like every other HTML file, while all other requests,
+// including the HMR WebSocket, are passed through with the base path of the
+// app stripped. The dev server serves from its root in both cases.
+type viteProxy struct {
+	target   *url.URL
+	basePath string
+	client   *http.Client
+	proxy    *httputil.ReverseProxy
+}
+
+func newViteProxy(rawURL string, basePath string) (*viteProxy, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Vite dev server URL: %w", err)
+	}
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("invalid Vite dev server URL '%s': expected an absolute http or https URL", rawURL)
+	}
+
+	p := &viteProxy{
+		target:   target,
+		basePath: configuration.NormalizeBasePath(basePath),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			// the same URL Open requests for a document
+			if rest, ok := strings.CutPrefix(r.Out.URL.Path, p.basePath); ok && p.basePath != "/" {
+				r.Out.URL.Path = "/" + rest
+				r.Out.URL.RawPath = ""
+			}
+			r.SetURL(target)
+			r.SetXForwarded()
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if resp.StatusCode == http.StatusNotFound {
+				return errViteNotFound
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, errViteNotFound) {
+				if result, ok := r.Context().Value(viteProxyResultKey{}).(*viteProxyResult); ok {
+					result.notFound = true
+					return
+				}
+				http.NotFound(w, r)
+				return
+			}
+
+			log.Printf("Failed to proxy '%s' to the Vite dev server at %s: %v\n", r.URL.Path, target, err)
+			http.Error(w, "Vite dev server unavailable", http.StatusBadGateway)
+		},
+	}
+
+	return p, nil
+}
+
+// Open fetches the HTML document name from the dev server. Everything else
+// does not exist for the FSList, it is proxied by serve instead.
+func (p *viteProxy) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if path.Ext(name) != ".html" {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.target.JoinPath(name).String(), nil)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fmt.Errorf("unexpected status %s", resp.Status)}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	info := &memFileInfo{
+		name: path.Base(name),
+		size: int64(len(data)),
+		mode: 0o444,
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.modTime = modTime
+	}
+
+	return newMemFile(data, info), nil
+}
+
+// serve proxies r to the dev server unless it asks for an HTML document. It
+// reports false if the request is left to the FSList, either because it is a
+// document or because the dev server does not know the file.
+func (p *viteProxy) serve(w http.ResponseWriter, r *http.Request, name string) bool {
+	if !isWebSocketUpgrade(r) && isDocumentRequest(r, name) {
+		return false
+	}
+
+	result := &viteProxyResult{}
+	p.proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), viteProxyResultKey{}, result)))
+
+	return !result.notFound
+}
+
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+// isDocumentRequest reports whether r asks for a page rather than a module or
+// asset: an HTML file, a directory, or an extensionless route of the SPA.
+func isDocumentRequest(r *http.Request, name string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	switch path.Ext(name) {
+	case ".html":
+		return true
+	case "":
+		return name == "." || strings.HasSuffix(r.URL.Path, "/") || strings.Contains(r.Header.Get("Accept"), "text/html")
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package backend
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+// newFakeViteServer mimics the parts of the Vite dev server the proxy relies on.
+func newFakeViteServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.html":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<title>%APP_CONFIG_GENERAL_NAME%</title><script type="module" src="/@vite/client"></script>`)
+		case "/src/main.tsx":
+			w.Header().Set("Content-Type", "text/javascript")
+			io.WriteString(w, "query="+r.URL.RawQuery)
+		case "/":
+			if r.Header.Get("Upgrade") != "websocket" {
+				http.NotFound(w, r)
+				return
+			}
+
+			conn, rw, err := http.NewResponseController(w).Hijack()
+			if err != nil {
+				t.Errorf("failed to hijack connection: %v", err)
+				return
+			}
+			defer conn.Close()
+
+			rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+			rw.Flush()
+
+			line, _ := rw.ReadString('\n')
+			rw.WriteString("echo " + line)
+			rw.Flush()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func newViteProxyFSList(t *testing.T, viteURL string, basePath string) *httptest.Server {
+	t.Helper()
+
+	proxy, err := newViteProxy(viteURL, basePath)
+	if err != nil {
+		t.Fatalf("unexpected error creating proxy: %v", err)
+	}
+
+	htmlVars := map[string]string{"%APP_CONFIG_GENERAL_NAME%": "MyApp"}
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{IndexFallback: true, BasePath: basePath}}
+	publicFS := fstest.MapFS{
+		"favicon.ico": &fstest.MapFile{Data: []byte("icon")},
+	}
+
+	fsList, err := NewFSList(true, cfg, htmlVars, FSItem{fs: proxy}, FSItem{fs: publicFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.SetPathValue("path", strings.TrimPrefix(r.URL.Path, configuration.NormalizeBasePath(basePath)))
+		if err := fsList.serve(w, r); err != nil {
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func getBody(t *testing.T, url string, accept string) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating request: %v", err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error requesting %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading %s: %v", url, err)
+	}
+	return resp, string(body)
+}
+
+func TestViteProxyServesModulesAndFallsBack(t *testing.T) {
+	vite := newFakeViteServer(t)
+	defer vite.Close()
+
+	server := newViteProxyFSList(t, vite.URL, "")
+	defer server.Close()
+
+	resp, body := getBody(t, server.URL+"/src/main.tsx?import", "*/*")
+	if body != "query=import" {
+		t.Fatalf("expected the module with its query, got %q", body)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/javascript" {
+		t.Fatalf("expected the content type of the dev server, got %q", got)
+	}
+
+	resp, body = getBody(t, server.URL+"/favicon.ico", "*/*")
+	if resp.StatusCode != http.StatusOK || body != "icon" {
+		t.Fatalf("expected pb_public fallback, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestViteProxyTransformsDocuments(t *testing.T) {
+	vite := newFakeViteServer(t)
+	defer vite.Close()
+
+	server := newViteProxyFSList(t, vite.URL, "")
+	defer server.Close()
+
+	expected := `<title>MyApp</title><script type="module" src="/@vite/client"></script>`
+	for _, urlPath := range []string{"/index.html", "/about"} {
+		_, body := getBody(t, server.URL+urlPath, "text/html")
+		if body != expected {
+			t.Fatalf("expected transformed index for %s, got %q", urlPath, body)
+		}
+	}
+}
+
+func TestViteProxyStripsBasePath(t *testing.T) {
+	vite := newFakeViteServer(t)
+	defer vite.Close()
+
+	server := newViteProxyFSList(t, vite.URL, "tools/sfs")
+	defer server.Close()
+
+	_, body := getBody(t, server.URL+"/tools/sfs/src/main.tsx?import", "*/*")
+	if body != "query=import" {
+		t.Fatalf("expected the module below the base path, got %q", body)
+	}
+
+	_, body = getBody(t, server.URL+"/tools/sfs/", "text/html")
+	if !strings.Contains(body, "<title>MyApp</title>") || !strings.Contains(body, `src="/tools/sfs/@vite/client"`) {
+		t.Fatalf("expected the rebased document, got %q", body)
+	}
+}
+
+func TestViteProxyPassesWebSocketUpgrades(t *testing.T) {
+	vite := newFakeViteServer(t)
+	defer vite.Close()
+
+	server := newViteProxyFSList(t, vite.URL, "")
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("unexpected error dialing: %v", err)
+	}
+	defer conn.Close()
+
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Protocol: vite-hmr\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("unexpected error reading handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+
+	io.WriteString(conn, "ping\n")
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("unexpected error reading upgraded connection: %v", err)
+	}
+	if line != "echo ping\n" {
+		t.Fatalf("expected echoed message, got %q", line)
+	}
+}
+
+func TestNewViteProxyRejectsInvalidURLs(t *testing.T) {
+	for _, rawURL := range []string{"localhost:5173", "ftp://localhost", "/relative"} {
+		if _, err := newViteProxy(rawURL, ""); err == nil {
+			t.Fatalf("expected an error for %q", rawURL)
+		}
+	}
+}
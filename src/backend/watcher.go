@@ -0,0 +1,155 @@
+package backend
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// watchPollInterval is how often directories are rescanned when the
+	// platform has no file change notifications.
+	watchPollInterval = time.Second
+
+	// watchDebounce coalesces the burst of changes a rebuild produces into a
+	// single notification.
+	watchDebounce = 150 * time.Millisecond
+)
+
+// watchDirs calls onChange for every file or directory below roots that is
+// created, modified or removed until ctx is done. The name is relative to its
+// root and slash separated, an empty name means everything below root may
+// have changed. Native notifications are used where available, otherwise the
+// directories are polled.
+func watchDirs(ctx context.Context, roots []string, onChange func(root string, name string)) {
+	err := watchNative(ctx, roots, onChange)
+	if err == nil {
+		return
+	}
+
+	log.Printf("File change notifications unavailable (%v), falling back to polling every %s.\n", err, watchPollInterval)
+	go watchPolling(ctx, roots, watchPollInterval, onChange)
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	isDir   bool
+}
+
+func snapshotDir(root string) map[string]fileState {
+	snapshot := make(map[string]fileState)
+
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+
+		snapshot[filepath.ToSlash(rel)] = fileState{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+			isDir:   info.IsDir(),
+		}
+		return nil
+	})
+
+	return snapshot
+}
+
+func watchPolling(ctx context.Context, roots []string, interval time.Duration, onChange func(root string, name string)) {
+	snapshots := make([]map[string]fileState, len(roots))
+	for i, root := range roots {
+		snapshots[i] = snapshotDir(root)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for i, root := range roots {
+			next := snapshotDir(root)
+
+			for name, state := range next {
+				previous, ok := snapshots[i][name]
+				if !ok || (!state.isDir && state != previous) {
+					onChange(root, name)
+				}
+			}
+
+			for name := range snapshots[i] {
+				if _, ok := next[name]; !ok {
+					onChange(root, name)
+				}
+			}
+
+			snapshots[i] = next
+		}
+	}
+}
+
+// Watch watches the directories of the mutable filesystems. Changed names are
+// dropped from the cache, so files of a later layer (e.g. the embedded bundle
+// in dev mode) no longer shadow them, and after a short quiet period onChange
+// is called with everything that changed.
+func (f *FSList) Watch(ctx context.Context, onChange func(names []string)) {
+	roots := make([]string, 0, len(f.filesystems))
+	for _, fsItem := range f.filesystems {
+		if fsItem.root != "" && !fsItem.immutable {
+			roots = append(roots, fsItem.root)
+		}
+	}
+
+	if len(roots) == 0 {
+		return
+	}
+
+	var mutex sync.Mutex
+	var timer *time.Timer
+	pending := make(map[string]struct{})
+
+	flush := func() {
+		mutex.Lock()
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		clear(pending)
+		mutex.Unlock()
+
+		f.cache.invalidate(names...)
+		if onChange != nil {
+			onChange(names)
+		}
+	}
+
+	watchDirs(ctx, roots, func(_ string, name string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		pending[name] = struct{}{}
+		if timer == nil {
+			timer = time.AfterFunc(watchDebounce, flush)
+		} else {
+			timer.Reset(watchDebounce)
+		}
+	})
+}
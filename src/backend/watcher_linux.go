@@ -0,0 +1,137 @@
+//go:build linux
+
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE |
+	syscall.IN_CLOSE_WRITE |
+	syscall.IN_MODIFY |
+	syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO
+
+type inotifyDir struct {
+	root string
+	path string
+}
+
+type inotifyWatcher struct {
+	fd   int
+	file *os.File
+	dirs map[int32]inotifyDir
+}
+
+// watchNative watches roots recursively with inotify. Roots that do not exist
+// yet are skipped.
+func watchNative(ctx context.Context, roots []string, onChange func(root string, name string)) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	// A non-blocking descriptor is handled by the runtime poller, so closing
+	// the file interrupts a pending read.
+	watcher := &inotifyWatcher{
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: make(map[int32]inotifyDir),
+	}
+
+	for _, root := range roots {
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := watcher.addTree(root, root); err != nil {
+			watcher.file.Close()
+			return err
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		watcher.file.Close()
+	}()
+
+	go watcher.run(roots, onChange)
+
+	return nil
+}
+
+func (w *inotifyWatcher) addTree(root string, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+
+		wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyMask)
+		if err != nil {
+			return fmt.Errorf("failed to watch '%s': %w", path, err)
+		}
+
+		w.dirs[int32(wd)] = inotifyDir{root: root, path: path}
+		return nil
+	})
+}
+
+func (w *inotifyWatcher) run(roots []string, onChange func(root string, name string)) {
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(event.Len)
+			offset = nameEnd
+
+			if nameEnd > n {
+				break
+			}
+
+			if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				for _, root := range roots {
+					onChange(root, "")
+				}
+				continue
+			}
+
+			dir, ok := w.dirs[event.Wd]
+			if !ok {
+				continue
+			}
+
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				delete(w.dirs, event.Wd)
+				continue
+			}
+
+			path := filepath.Join(dir.path, strings.TrimRight(string(buf[nameStart:nameEnd]), "\x00"))
+
+			if event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				_ = w.addTree(dir.root, path)
+			}
+
+			rel, err := filepath.Rel(dir.root, path)
+			if err != nil {
+				continue
+			}
+
+			onChange(dir.root, filepath.ToSlash(rel))
+		}
+	}
+}
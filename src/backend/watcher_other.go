@@ -0,0 +1,13 @@
+//go:build !linux
+
+package backend
+
+import (
+	"context"
+	"errors"
+)
+
+// watchNative is only implemented with inotify on Linux.
+func watchNative(ctx context.Context, roots []string, onChange func(root string, name string)) error {
+	return errors.New("no native file watcher for this platform")
+}
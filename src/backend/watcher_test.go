@@ -0,0 +1,199 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/configuration"
+)
+
+func waitForName(t *testing.T, changes <-chan string, name string) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case changed := <-changes:
+			if changed == name {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("expected a change of %q", name)
+		}
+	}
+}
+
+func TestWatchDirsDetectsChanges(t *testing.T) {
+	watchers := map[string]func(ctx context.Context, root string, onChange func(root string, name string)){
+		"native": func(ctx context.Context, root string, onChange func(root string, name string)) {
+			watchDirs(ctx, []string{root}, onChange)
+		},
+		"polling": func(ctx context.Context, root string, onChange func(root string, name string)) {
+			go watchPolling(ctx, []string{root}, 20*time.Millisecond, onChange)
+		},
+	}
+
+	for label, watch := range watchers {
+		t.Run(label, func(t *testing.T) {
+			root := t.TempDir()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			changes := make(chan string, 64)
+			watch(ctx, root, func(_ string, name string) {
+				select {
+				case changes <- name:
+				default:
+				}
+			})
+
+			// Give the poller its initial snapshot before changing anything.
+			time.Sleep(50 * time.Millisecond)
+
+			if err := os.Mkdir(filepath.Join(root, "assets"), 0o755); err != nil {
+				t.Fatalf("failed to create directory: %v", err)
+			}
+			waitForName(t, changes, "assets")
+
+			// The new directory has to be watched before writing into it.
+			time.Sleep(50 * time.Millisecond)
+
+			if err := os.WriteFile(filepath.Join(root, "assets", "app.js"), []byte("v1"), 0o644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+			waitForName(t, changes, "assets/app.js")
+		})
+	}
+}
+
+func TestFSListWatchInvalidatesShadowedEntries(t *testing.T) {
+	root := t.TempDir()
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{
+		StaticFileServerImmutable: true,
+		LiveReload:                true,
+	}}
+	embedded := fstest.MapFS{
+		"app.js": &fstest.MapFile{Data: []byte("embedded")},
+	}
+
+	fsList, err := NewFSList(true, cfg, nil, newDirFSItem(root), FSItem{fs: embedded, immutable: true})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+
+	if got := readAll(t, &fsList, "app.js"); got != "embedded" {
+		t.Fatalf("expected embedded content, got %q", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan []string, 1)
+	fsList.Watch(ctx, func(names []string) {
+		changed <- names
+	})
+
+	if err := os.WriteFile(filepath.Join(root, "app.js"), []byte("on disk"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	select {
+	case names := <-changed:
+		if len(names) != 1 || names[0] != "app.js" {
+			t.Fatalf("expected [app.js], got %v", names)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a change notification")
+	}
+
+	if got := readAll(t, &fsList, "app.js"); got != "on disk" {
+		t.Fatalf("expected the new file to shadow the embedded one, got %q", got)
+	}
+}
+
+func readAll(t *testing.T, fsList *FSList, name string) string {
+	t.Helper()
+
+	file, err := fsList.Open(name)
+	if err != nil {
+		t.Fatalf("unexpected error opening %s: %v", name, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("unexpected error reading %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestLiveReloadScriptOnlyInDevMode(t *testing.T) {
+	baseFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<html><BODY>hi</BODY></html>")},
+	}
+	cfg := configuration.AppConfig{Server: configuration.ServerConfig{LiveReload: true}}
+
+	devList, err := NewFSList(true, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+	expected := "<html><BODY>hi" + string(liveReloadScript(liveReloadPath)) + "</BODY></html>"
+	if got := readAll(t, &devList, "index.html"); got != expected {
+		t.Fatalf("expected script before </body>, got %q", got)
+	}
+
+	prodList, err := NewFSList(false, cfg, nil, FSItem{fs: baseFS})
+	if err != nil {
+		t.Fatalf("unexpected error creating FSList: %v", err)
+	}
+	if got := readAll(t, &prodList, "index.html"); strings.Contains(got, liveReloadPath) {
+		t.Fatalf("expected no live reload script outside dev mode, got %q", got)
+	}
+}
+
+func TestLiveReloadStreamsEvents(t *testing.T) {
+	reloader := newLiveReload()
+	server := httptest.NewServer(reloader)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", got)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if line, _ := reader.ReadString('\n'); line != "retry: 1000\n" {
+		t.Fatalf("expected retry line, got %q", line)
+	}
+
+	reloader.notify([]string{"index.html"})
+
+	var lines []string
+	for len(lines) < 2 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("unexpected error reading stream: %v", err)
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if lines[0] != "event: reload" || lines[1] != `data: ["index.html"]` {
+		t.Fatalf("unexpected event %v", lines)
+	}
+}
@@ -4,6 +4,7 @@ import { Route, RouteSectionProps, Router } from "@solidjs/router";
 
 import Layout from "./pages/well-known/Layout";
 import { canCreateAdmin, isAuthenticated } from "./service/api/user";
+import { basePath } from "./service/basePath";
 
 export interface PageDefinition {
   title?: string;
@@ -131,13 +132,19 @@ export function App(props: {
   return (
     <>
       {canCreateAdmin() === true ?
-        <Router root={props.wellKnown.minimalLayout.component}>
+        <Router
+          base={basePath}
+          root={props.wellKnown.minimalLayout.component}
+        >
           <Route
             path="*"
             component={props.wellKnown.createAdminUser.component}
           />
         </Router>
-      : <Router root={AppLayout(props.wellKnown, props.pageList)}>
+      : <Router
+          base={basePath}
+          root={AppLayout(props.wellKnown, props.pageList)}
+        >
           <For
             each={props.pageList}
             children={(page) => (
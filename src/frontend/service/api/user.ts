@@ -2,6 +2,7 @@ import { Accessor, createSignal } from "solid-js";
 
 import { ClientResponseError } from "pocketbase";
 
+import { apiPath } from "../basePath";
 import pb, { ResponseError, Result, err, ok } from "../pocketBase/pocketBase";
 
 /**
@@ -55,7 +56,7 @@ async function isAuthenticatedApi(): Promise<
       headers.Authorization = pb.authStore.token;
     }
 
-    const res = await fetch("/api/user/is-authenticated", {
+    const res = await fetch(apiPath("/api/user/is-authenticated"), {
       credentials: "include",
       headers,
     });
@@ -101,7 +102,7 @@ export async function createAdminUser(
     form.append("password", password);
     form.append("passwordConfirm", passwordConfirm);
 
-    const res = await fetch("/api/user/create-admin-user", {
+    const res = await fetch(apiPath("/api/user/create-admin-user"), {
       method: "POST",
       body: form,
     });
@@ -0,0 +1,20 @@
+/**
+ * Base Path Helpers
+ *
+ * The backend can serve the app below a path prefix (`server.basePath`). It
+ * then adds a matching `<base href>` to every HTML page, which is the single
+ * source of the prefix on the client.
+ */
+
+/** The base path without its trailing slash, an empty string at the root. */
+export const basePath: string = (
+  document.querySelector("base")?.getAttribute("href") ?? "/"
+).replace(/\/+$/, "");
+
+/**
+ * Returns the absolute path of a custom backend route like `/api/user/exists`.
+ * The PocketBase API itself always stays at the root.
+ */
+export function apiPath(route: string): string {
+  return basePath + route;
+}
@@ -0,0 +1,18 @@
+// Code generated by the backend from the public fields of AppConfig. DO NOT EDIT.
+
+interface AppPublicConfig {
+  general: {
+    /** The application name. */
+    name: string;
+    /** A brief description of the application. */
+    description: string;
+    /** The current version of the application. */
+    version: string;
+    /** The URL this application is hosted at. */
+    url: string;
+  };
+}
+
+interface Window {
+  __APP_CONFIG__: AppPublicConfig;
+}
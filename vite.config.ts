@@ -11,6 +11,153 @@ import webfontDownload from "vite-plugin-webfont-dl";
 
 const root = resolve(__dirname, "src/frontend");
 
+type SyntaxNode = { type: string; [key: string]: unknown };
+
+function isSyntaxNode(value: unknown): value is SyntaxNode {
+  return (
+    typeof value === "object" &&
+    value !== null &&
+    typeof (value as SyntaxNode).type === "string"
+  );
+}
+
+function findCall(node: unknown, callee: string): SyntaxNode | undefined {
+  if (Array.isArray(node)) {
+    for (const child of node) {
+      const found = findCall(child, callee);
+      if (found) {
+        return found;
+      }
+    }
+    return undefined;
+  }
+
+  if (!isSyntaxNode(node)) {
+    return undefined;
+  }
+
+  if (
+    node.type === "CallExpression" &&
+    isSyntaxNode(node.callee) &&
+    node.callee.type === "Identifier" &&
+    node.callee.name === callee
+  ) {
+    return node;
+  }
+
+  for (const value of Object.values(node)) {
+    const found = findCall(value, callee);
+    if (found) {
+      return found;
+    }
+  }
+  return undefined;
+}
+
+function propertyKey(property: SyntaxNode): string | undefined {
+  const key = property.key;
+  if (!isSyntaxNode(key)) {
+    return undefined;
+  }
+  if (key.type === "Identifier" && !property.computed) {
+    return key.name as string;
+  }
+  if (key.type === "Literal" && typeof key.value === "string") {
+    return key.value;
+  }
+  return undefined;
+}
+
+function normalizeRoutePath(path: string): string {
+  return (
+    "/" +
+    path
+      .split("/")
+      .map((segment) => segment.trim())
+      .filter((segment) => segment.length > 0)
+      .join("/")
+  );
+}
+
+/**
+ * Collects the route paths of a page tree literal, mirroring
+ * `flattenAppPages` in `src/frontend/router.tsx`.
+ */
+function collectRoutes(
+  tree: SyntaxNode,
+  parent: string,
+  depth: number,
+): string[] {
+  const routes: string[] = [];
+  for (const property of tree.properties as SyntaxNode[]) {
+    const key = propertyKey(property);
+    if (key === undefined || (depth === 0 && key === "well-known")) {
+      continue;
+    }
+
+    const path = normalizeRoutePath(`${parent}/${key}`);
+    routes.push(path);
+
+    if (!isSyntaxNode(property.value)) {
+      continue;
+    }
+    for (const child of property.value.properties as SyntaxNode[]) {
+      if (
+        propertyKey(child) === "children" &&
+        isSyntaxNode(child.value) &&
+        child.value.type === "ObjectExpression"
+      ) {
+        routes.push(...collectRoutes(child.value, path, depth + 1));
+      }
+    }
+  }
+  return routes;
+}
+
+/**
+ * Emits `routes.json` with the routes of the page tree passed to
+ * `transformPageTree` in the entry module. The backend answers SPA fallbacks
+ * for paths matching none of them with status 404.
+ */
+function routeManifest(entry: string): Plugin {
+  let routes: string[] | undefined;
+
+  return {
+    name: "route-manifest",
+    apply: "build",
+    enforce: "post",
+    transform(code, id) {
+      if (id.split("?")[0] !== entry) {
+        return null;
+      }
+
+      const call = findCall(this.parse(code), "transformPageTree");
+      const tree = (call?.arguments as SyntaxNode[] | undefined)?.[0];
+      if (tree?.type !== "ObjectExpression") {
+        this.warn(
+          "no page tree literal passed to transformPageTree, skipping route manifest",
+        );
+        routes = undefined;
+        return null;
+      }
+
+      routes = collectRoutes(tree, "", 0);
+      return null;
+    },
+    generateBundle() {
+      if (routes === undefined) {
+        return;
+      }
+
+      this.emitFile({
+        type: "asset",
+        fileName: "routes.json",
+        source: JSON.stringify({ routes }, null, 2),
+      });
+    },
+  };
+}
+
 export default defineConfig({
   root: root,
   plugins: [
@@ -24,6 +171,7 @@ export default defineConfig({
       compiler: "solid",
     }),
     LightningCSS(),
+    routeManifest(resolve(root, "index.tsx")),
     viteCompression({
       algorithm: "brotliCompress",
       ext: ".br",